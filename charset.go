@@ -0,0 +1,94 @@
+package gomtch
+
+import (
+	"fmt"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithCharset declares the charset of the bytes read by NewDocumentFromReader (ex: "windows-1252",
+// "iso-8859-1", "utf-16le"). They are transcoded to UTF-8 before the normalization options run.
+// The names are the ones of the WHATWG Encoding Standard, so "iso-8859-1" is read as Windows-1252.
+func WithCharset(name string) Option {
+	return func(d *Document) {
+		e, canonical := charset.Lookup(name)
+		if e == nil {
+			d.optError = fmt.Errorf("unknown charset %q", name)
+			return
+		}
+		d.charset = canonical
+		d.encoding = e
+	}
+}
+
+// WithCharsetDetection sniffs the charset of the bytes read by NewDocumentFromReader and transcodes
+// them to UTF-8 before the normalization options run. A byte order mark is trusted first, then a text
+// that is valid UTF-8 is read as such and then the charset declared by an HTML meta tag is used.
+// Anything else is read as Windows-1252, the superset of ISO-8859-1 used by legacy systems.
+// The charset found is returned by the Charset method of the Document.
+func WithCharsetDetection() Option {
+	return func(d *Document) {
+		d.detectCharset = true
+	}
+}
+
+// WithStrictUTF8 makes the Documents fail to be created from a text that is not valid UTF-8
+// after it was transcoded, instead of comparing its invalid bytes as U+FFFD.
+func WithStrictUTF8() Option {
+	return func(d *Document) {
+		d.strictUTF8 = true
+	}
+}
+
+// Charset returns the charset the text was read with by NewDocumentFromReader when it was
+// declared by WithCharset or found by WithCharsetDetection.
+func (d Document) Charset() string {
+	return d.charset
+}
+
+// decodeCharset returns the bytes read as UTF-8 and the charset they were read with.
+func (d Document) decodeCharset(b []byte) (string, string, error) {
+	e, name := d.encoding, d.charset
+	if e == nil && d.detectCharset {
+		e, name = detectCharset(b)
+	}
+	if name == "" {
+		return string(b), name, nil
+	}
+	if e == nil || name == "utf-8" {
+		// the text is copied as it is so the invalid bytes can be reported
+		return strings.TrimPrefix(string(b), "\ufeff"), name, nil
+	}
+	decoded, err := e.NewDecoder().Bytes(b)
+	if err != nil {
+		return "", name, fmt.Errorf("decoding %s: %w", name, err)
+	}
+	return strings.TrimPrefix(string(decoded), "\ufeff"), name, nil
+}
+
+// detectCharset sniffs the charset of the bytes.
+func detectCharset(b []byte) (encoding.Encoding, string) {
+	e, name, certain := charset.DetermineEncoding(b, "")
+	if certain {
+		return e, name
+	}
+	// DetermineEncoding only looks at the first 1024 bytes
+	if utf8.Valid(b) {
+		return nil, "utf-8"
+	}
+	return e, name
+}
+
+// checkUTF8 returns an error telling where the text stops being valid UTF-8.
+func checkUTF8(text string) error {
+	for i, r := range text {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(text[i:]); size == 1 {
+				return fmt.Errorf("invalid UTF-8 at byte %d", i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package gomtch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewDocumentFromReader_Charset(t *testing.T) {
+	longASCII := strings.Repeat("a ", 600)
+	tests := []struct {
+		name        string
+		input       []byte
+		opts        []Option
+		want        string
+		wantCharset string
+		wantErr     bool
+	}{
+		{"declared", []byte("coca\xedna"), []Option{WithCharset("windows-1252")}, "cocaína", "windows-1252", false},
+		{"declaredLatin1", []byte("can\xe7\xe3o"), []Option{WithCharset("ISO-8859-1")}, "canção", "windows-1252", false},
+		{"unknownCharset", []byte("cocaína"), []Option{WithCharset("klingon")}, "", "", true},
+		{"detectedUTF8", []byte("cocaína"), []Option{WithCharsetDetection()}, "cocaína", "utf-8", false},
+		{"detectedUTF8AfterPreview", []byte(longASCII + "cocaína"), []Option{WithCharsetDetection()},
+			longASCII + "cocaína", "utf-8", false},
+		{"detectedUTF8BOM", []byte("\xef\xbb\xbfcocaína"), []Option{WithCharsetDetection()}, "cocaína", "utf-8", false},
+		{"detectedUTF16BOM", []byte("\xff\xfec\x00o\x00c\x00a\x00\xed\x00n\x00a\x00"), []Option{WithCharsetDetection()},
+			"cocaína", "utf-16le", false},
+		{"detectedLegacy", []byte("coca\xedna"), []Option{WithCharsetDetection()}, "cocaína", "windows-1252", false},
+		{"detectedMeta", []byte("<meta charset=\"iso-8859-2\"><p>\xb3\xf3d\xbc</p>"), []Option{WithCharsetDetection()},
+			`<meta charset="iso-8859-2"><p>łódź</p>`, "iso-8859-2", false},
+		{"strict", []byte("coca\xedna"), []Option{WithStrictUTF8()}, "", "", true},
+		{"strictTranscoded", []byte("coca\xedna"), []Option{WithStrictUTF8(), WithCharsetDetection()},
+			"cocaína", "windows-1252", false},
+		{"notStrict", []byte("coca\xedna"), nil, "coca\xedna", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocumentFromReader(bytes.NewReader(tt.input), tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDocumentFromReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if d.Text != tt.want || d.Charset() != tt.wantCharset {
+				t.Errorf("NewDocumentFromReader() = %q %q, want %q %q", d.Text, d.Charset(), tt.want, tt.wantCharset)
+			}
+		})
+	}
+}
+
+func TestWithStrictUTF8(t *testing.T) {
+	_, err := NewDocument("coca\xedna", WithStrictUTF8())
+	if err == nil || err.Error() != "invalid UTF-8 at byte 4" {
+		t.Errorf("NewDocument() error = %v", err)
+	}
+	if _, err := NewDocument("cocaína �", WithStrictUTF8()); err != nil {
+		t.Errorf("NewDocument() error = %v", err)
+	}
+}
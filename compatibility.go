@@ -0,0 +1,80 @@
+package gomtch
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strconv"
+	"strings"
+)
+
+// smallCapitals maps the small capital letters, which have no compatibility decomposition,
+// to the lowercase letters.
+var smallCapitals = map[rune]string{
+	'ᴀ': "a", 'ʙ': "b", 'ᴄ': "c", 'ᴅ': "d", 'ᴇ': "e", 'ꜰ': "f", 'ɢ': "g", 'ʜ': "h", 'ɪ': "i", 'ᴊ': "j",
+	'ᴋ': "k", 'ʟ': "l", 'ᴍ': "m", 'ɴ': "n", 'ᴏ': "o", 'ᴘ': "p", 'ꞯ': "q", 'ʀ': "r", 'ꜱ': "s", 'ᴛ': "t",
+	'ᴜ': "u", 'ᴠ': "v", 'ᴡ': "w", 'ʏ': "y", 'ᴢ': "z", 'ᴁ': "ae", 'ɶ': "oe",
+}
+
+// compatibilityExtra returns the mapping of the characters NFKC keeps as they are although they are
+// styled forms of letters and numbers: negative circled and squared letters, regional indicator
+// symbols, small capitals and the circled numbers of the dingbats.
+func compatibilityExtra(r rune) (string, bool) {
+	switch {
+	case r >= '\U0001f150' && r <= '\U0001f169': // negative circled 🅐
+		return string('A' + r - '\U0001f150'), true
+	case r >= '\U0001f170' && r <= '\U0001f189': // negative squared 🅰
+		return string('A' + r - '\U0001f170'), true
+	case r >= '\U0001f1e6' && r <= '\U0001f1ff': // regional indicator 🇦
+		return string('A' + r - '\U0001f1e6'), true
+	case r >= '❶' && r <= '❿': // dingbat negative circled ❶
+		return strconv.Itoa(int(r-'❶') + 1), true
+	case r >= '➀' && r <= '➉': // dingbat circled sans-serif ➀
+		return strconv.Itoa(int(r-'➀') + 1), true
+	case r >= '➊' && r <= '➓': // dingbat negative circled sans-serif ➊
+		return strconv.Itoa(int(r-'➊') + 1), true
+	case r >= '⓫' && r <= '⓴': // negative circled ⓫
+		return strconv.Itoa(int(r-'⓫') + 11), true
+	case r >= '⓵' && r <= '⓾': // double circled ⓵
+		return strconv.Itoa(int(r-'⓵') + 1), true
+	case r == '⓿' || r == '\U0001f10b' || r == '\U0001f10c':
+		return "0", true
+	}
+	v, ok := smallCapitals[r]
+	return v, ok
+}
+
+// Compatibility folds the styled forms of the letters and numbers into the basic ones
+// (ex: 𝐜𝐨𝐫𝐩𝐨𝐫𝐚, ｃｏｒｐｏｒａ, ⓒⓞⓡⓟⓞⓡⓐ and 🅲🅾🆁🅿🅾🆁🅰 = corpora or CORPORA). It applies the
+// compatibility normalization (NFKC) and maps the characters NFKC misses, such as the
+// regional indicator symbols, the negative squared letters and the small capitals.
+type Compatibility struct{}
+
+func NewCompatibility() *Compatibility {
+	return &Compatibility{}
+}
+
+func (c Compatibility) Transform(s string) (string, error) {
+	folded := norm.NFKC.String(s)
+	var b strings.Builder
+	b.Grow(len(folded))
+	for _, r := range folded {
+		if v, ok := compatibilityExtra(r); ok {
+			b.WriteString(v)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), nil
+}
+
+// WithCompatibilityFolding folds the styled forms of the letters and numbers with Compatibility.
+// It runs before the case folding options, which only see the basic letters.
+func WithCompatibilityFolding() Option {
+	compatibility := NewCompatibility()
+	return func(d *Document) {
+		d.addStep(StageCompatibilityFolding, "WithCompatibilityFolding", func(d *Document) {
+			if err := d.applyLocal(compatibility.Transform); err != nil {
+				d.optError = err
+			}
+		})
+	}
+}
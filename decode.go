@@ -0,0 +1,383 @@
+package gomtch
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxEntityDecoding is the number of times the entities are decoded, for the ones encoded
+// more than once (ex: "&amp;#99;").
+const maxEntityDecoding = 3
+
+// WithEntityDecoding decodes the HTML character references of the text, named or numeric
+// (ex: "&#99;&#111;rpora" = corpora), including the ones encoded more than once.
+func WithEntityDecoding() Option {
+	return func(d *Document) {
+		d.addStep(StageDecode, "WithEntityDecoding", func(d *Document) {
+			for i := 0; i < maxEntityDecoding && strings.Contains(d.Text, "&"); i++ {
+				decoded := html.UnescapeString(d.Text)
+				if decoded == d.Text {
+					break
+				}
+				if d.offsets == nil {
+					d.Text = decoded
+					continue
+				}
+				b := d.newTextBuilder(len(decoded))
+				writeEntities(b, d.Text, 0)
+				if b.String() != decoded {
+					d.setText(decoded, wholeMap(d.Text, decoded))
+					continue
+				}
+				b.setText(d)
+			}
+		})
+	}
+}
+
+// WithPercentDecoding decodes the percent-encoded bytes of the text (ex: "%63orpora" = corpora).
+// Sequences that do not decode to valid UTF-8 are kept as they are.
+func WithPercentDecoding() Option {
+	return func(d *Document) {
+		d.addStep(StageDecode, "WithPercentDecoding", func(d *Document) {
+			if !strings.Contains(d.Text, "%") {
+				return
+			}
+			b := d.newTextBuilder(len(d.Text))
+			decodePercent(b, d.Text)
+			b.setText(d)
+		})
+	}
+}
+
+// writeEntities writes the text with its HTML character references decoded, as html.UnescapeString
+// does, each one in place of its own bytes. offset is the offset of the text in the text given to the step.
+func writeEntities(b *textBuilder, text string, offset int) {
+	for i := 0; i < len(text); {
+		amp := strings.IndexByte(text[i:], '&')
+		if amp < 0 {
+			b.write(text[i:], offset+i, offset+len(text))
+			return
+		}
+		b.write(text[i:i+amp], offset+i, offset+i+amp)
+		i += amp
+		end := entityEnd(text, i)
+		b.write(html.UnescapeString(text[i:end]), offset+i, offset+end)
+		i = end
+	}
+}
+
+// entityEnd returns the offset after the character reference starting at the ampersand at i: the
+// longest one html.UnescapeString may read.
+func entityEnd(text string, i int) int {
+	j := i + 1
+	if j < len(text) && text[j] == '#' {
+		j++
+		if j < len(text) && (text[j] == 'x' || text[j] == 'X') {
+			j++
+		}
+	}
+	for j < len(text) && text[j] < utf8.RuneSelf && isAlphanumeric(rune(text[j])) {
+		j++
+	}
+	if j < len(text) && text[j] == ';' {
+		j++
+	}
+	return j
+}
+
+func decodePercent(b *textBuilder, text string) {
+	for i := 0; i < len(text); {
+		var run []byte
+		j := i
+		for j+2 < len(text) && text[j] == '%' && isHex(text[j+1]) && isHex(text[j+2]) {
+			v, _ := strconv.ParseUint(text[j+1:j+3], 16, 8)
+			run = append(run, byte(v))
+			j += 3
+		}
+		if run == nil {
+			b.write(text[i:i+1], i, i+1)
+			i++
+			continue
+		}
+		if utf8.Valid(run) {
+			b.write(string(run), i, j)
+		} else {
+			b.write(text[i:j], i, j)
+		}
+		i = j
+	}
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// WithEscapeDecoding decodes the JavaScript and JSON escapes of the text: \uXXXX (including
+// surrogate pairs), \u{X...}, \xHH and the single character escapes (ex: "\u0063orpora" = corpora).
+// Unknown escapes are kept as they are.
+func WithEscapeDecoding() Option {
+	return func(d *Document) {
+		d.addStep(StageDecode, "WithEscapeDecoding", func(d *Document) {
+			if !strings.Contains(d.Text, `\`) {
+				return
+			}
+			b := d.newTextBuilder(len(d.Text))
+			decodeEscapes(b, d.Text)
+			b.setText(d)
+		})
+	}
+}
+
+var singleEscapes = map[byte]string{
+	'n': "\n", 't': "\t", 'r': "\r", 'b': "\b", 'f': "\f", 'v': "\v", '0': "\x00",
+	'\\': "\\", '"': "\"", '\'': "'", '/': "/",
+}
+
+func decodeEscapes(b *textBuilder, text string) {
+	for i := 0; i < len(text); {
+		if text[i] != '\\' || i+1 == len(text) {
+			b.write(text[i:i+1], i, i+1)
+			i++
+			continue
+		}
+		r, size := decodeEscape(text[i:])
+		if size == 0 {
+			if v, ok := singleEscapes[text[i+1]]; ok {
+				b.write(v, i, i+2)
+				i += 2
+				continue
+			}
+			b.write(text[i:i+1], i, i+1)
+			i++
+			continue
+		}
+		// a high surrogate followed by a low one is a single rune
+		if utf16.IsSurrogate(r) {
+			if low, lowSize := decodeEscape(text[i+size:]); lowSize != 0 {
+				if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+					r = pair
+					size += lowSize
+				}
+			}
+		}
+		b.writeRune(r, i, i+size)
+		i += size
+	}
+}
+
+// decodeEscape decodes the numeric escape at the start of s. It returns a zero size if there is none.
+func decodeEscape(s string) (rune, int) {
+	if len(s) < 4 || s[0] != '\\' {
+		return 0, 0
+	}
+	var digits string
+	var size int
+	switch {
+	case s[1] == 'u' && s[2] == '{':
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return 0, 0
+		}
+		digits, size = s[3:end], end+1
+	case s[1] == 'u' && len(s) >= 6:
+		digits, size = s[2:6], 6
+	case s[1] == 'x':
+		digits, size = s[2:4], 4
+	default:
+		return 0, 0
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || v > unicode.MaxRune {
+		return 0, 0
+	}
+	return rune(v), size
+}
+
+// SegmentEncoding is an encoding a part of the text can be hidden with.
+type SegmentEncoding int
+
+const (
+	// SegmentBase64 are the tokens encoded in standard or URL base64 (ex: Y29ycG9yYQ==).
+	SegmentBase64 SegmentEncoding = iota
+	// SegmentHex are the tokens encoded in hexadecimal (ex: 636f72706f7261 or 0x636f72706f7261).
+	SegmentHex
+	// SegmentROT13 is the whole text read in ROT13 (ex: pbecben = corpora). As any text can be
+	// ROT13 it is always decoded.
+	SegmentROT13
+	// SegmentAcrostic are the first letters of the lines of the text, read as a word.
+	SegmentAcrostic
+	// SegmentTelestich are the last letters of the lines of the text, read as a word.
+	SegmentTelestich
+	// SegmentVertical are the sequential lines of a single character, read as a word.
+	SegmentVertical
+)
+
+var segmentEncodingNames = map[SegmentEncoding]string{
+	SegmentBase64:    "base64",
+	SegmentHex:       "hex",
+	SegmentROT13:     "ROT13",
+	SegmentAcrostic:  "acrostic",
+	SegmentTelestich: "telestich",
+	SegmentVertical:  "vertical",
+}
+
+func (e SegmentEncoding) String() string {
+	if name, ok := segmentEncodingNames[e]; ok {
+		return name
+	}
+	return fmt.Sprintf("encoding(%d)", int(e))
+}
+
+// Segment is a part of the text decoded by WithSegmentDecoding. Offset is the byte offset where
+// Source comes from, in the Original text with WithOffsetTracking or in the text given to the step
+// that found it otherwise, and Text is the decoded text, before the normalization options that run
+// after the decode stage.
+// The segments read from the lines of the text have a token per line in Text and Lines holds
+// the number of the line of each token, starting at 1.
+type Segment struct {
+	Encoding SegmentEncoding
+	Offset   int
+	Source   string
+	Text     string
+	Lines    []int
+}
+
+func (s Segment) String() string {
+	return fmt.Sprintf("%s segment at offset %d", s.Encoding, s.Offset)
+}
+
+// minSegmentLength is the length of the shortest token decoded as base64 or hex.
+const minSegmentLength = 8
+
+// WithSegmentDecoding looks for the parts of the text hidden with the given encodings and decodes them.
+// The text is not changed: each Segment decoded goes through the remaining normalization options on its
+// own and is scanned after the text, the Match found in it tells its Segment. The encodings read from
+// the lines are given to WithLineReading instead.
+func WithSegmentDecoding(encodings ...SegmentEncoding) Option {
+	return func(d *Document) {
+		for _, e := range encodings {
+			if segmentEncodingIn(e, lineEncodings) {
+				d.optError = fmt.Errorf("%s is read from the lines by WithLineReading", e)
+				return
+			}
+		}
+		d.addStep(StageDecode, "WithSegmentDecoding", func(d *Document) {
+			for _, e := range encodings {
+				d.addSegments(findSegments(d.Text, e), nil)
+			}
+		})
+	}
+}
+
+func findSegments(text string, encoding SegmentEncoding) []Segment {
+	switch encoding {
+	case SegmentROT13:
+		return []Segment{{Encoding: SegmentROT13, Offset: 0, Source: text, Text: rot13(text)}}
+	}
+	var segments []Segment
+	eachField(text, func(offset int, token string) {
+		trimmed := strings.TrimLeft(token, `"'([{<`)
+		offset += len(token) - len(trimmed)
+		trimmed = strings.TrimRight(trimmed, `"'.,;:!?)]}>`)
+		if len(trimmed) < minSegmentLength {
+			return
+		}
+		var decoded []byte
+		var err error
+		switch encoding {
+		case SegmentHex:
+			digits := strings.TrimPrefix(strings.TrimPrefix(trimmed, "0x"), "0X")
+			decoded, err = hex.DecodeString(digits)
+		case SegmentBase64:
+			decoded, err = decodeBase64(trimmed)
+		default:
+			return
+		}
+		if err != nil || !isReadable(decoded) {
+			return
+		}
+		segments = append(segments, Segment{Encoding: encoding, Offset: offset, Source: trimmed, Text: string(decoded)})
+	})
+	return segments
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.URLEncoding
+	}
+	if !strings.HasSuffix(s, "=") && len(s)%4 != 0 {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+	return encoding.DecodeString(s)
+}
+
+// isReadable reports whether the bytes decoded are a text: valid UTF-8 made mostly of letters,
+// numbers and spaces, without control characters.
+func isReadable(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+	var total, readable int
+	for _, r := range string(b) {
+		total++
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == ' ':
+			readable++
+		case unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+		default:
+			return false
+		}
+	}
+	return readable*4 >= total*3
+}
+
+func rot13(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, text)
+}
+
+// addSegments records the segments found in the text being normalized, with their offsets
+// in the Original text if they are tracked, and the Spans of their tokens when they are known.
+func (d *Document) addSegments(segments []Segment, spans [][]Span) {
+	for i, s := range segments {
+		s.Offset = d.originalOffset(s.Offset)
+		d.segments = append(d.segments, s)
+		var tokenSpans []Span
+		if spans != nil {
+			tokenSpans = make([]Span, len(spans[i]))
+			for j, span := range spans[i] {
+				tokenSpans[j] = d.originalSpan(span)
+			}
+		}
+		d.segmentSpans = append(d.segmentSpans, tokenSpans)
+	}
+}
+
+// Segments returns the parts of the text decoded by WithSegmentDecoding.
+func (d Document) Segments() []Segment {
+	return d.segments
+}
+
+// Provenance describes where the Match was found (ex: "found in base64 segment at offset 12").
+func (m Match) Provenance() string {
+	if m.Segment == nil {
+		return "found in text"
+	}
+	return "found in " + m.Segment.String()
+}
@@ -0,0 +1,134 @@
+package gomtch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodingOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts []Option
+		want string
+	}{
+		{"entities", "&#99;&#111;rpora &amp; &#x63;ia", []Option{WithEntityDecoding()}, "corpora & cia"},
+		{"doubleEntities", "&amp;#99;orpora", []Option{WithEntityDecoding()}, "corpora"},
+		{"percent", "%63orpora%20now 100%", []Option{WithPercentDecoding()}, "corpora now 100%"},
+		{"percentUTF8", "coca%C3%ADna", []Option{WithPercentDecoding()}, "cocaína"},
+		{"percentInvalid", "coca%EDna", []Option{WithPercentDecoding()}, "coca%EDna"},
+		{"escapes", `corpora \x63ia \u{1F600} 😀`, []Option{WithEscapeDecoding()},
+			"corpora cia 😀 😀"},
+		{"singleEscapes", `"co\"r\/pora"\n\q`, []Option{WithEscapeDecoding()}, "\"co\"r/pora\"\n\\q"},
+		{"lowerAfterDecoding", "&#67;ORPORA", []Option{WithSetLower(), WithEntityDecoding()}, "corpora"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Text != tt.want {
+				t.Errorf("Text = %q, want %q", d.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSegmentDecoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		encodings []SegmentEncoding
+		want      []Segment
+	}{
+		{"base64", "buy (Y29ycG9yYQ==) now", []SegmentEncoding{SegmentBase64},
+			[]Segment{{Encoding: SegmentBase64, Offset: 5, Source: "Y29ycG9yYQ==", Text: "corpora"}}},
+		{"base64NoPadding", "Y29ycG9yYSBub3c", []SegmentEncoding{SegmentBase64},
+			[]Segment{{Encoding: SegmentBase64, Offset: 0, Source: "Y29ycG9yYSBub3c", Text: "corpora now"}}},
+		{"notBase64", "password corporation", []SegmentEncoding{SegmentBase64}, nil},
+		{"hex", "buy 0x636f72706f7261", []SegmentEncoding{SegmentHex},
+			[]Segment{{Encoding: SegmentHex, Offset: 4, Source: "0x636f72706f7261", Text: "corpora"}}},
+		{"notHex", "deadbeef 12345678", []SegmentEncoding{SegmentHex}, nil},
+		{"rot13", "ohl pbecben", []SegmentEncoding{SegmentROT13},
+			[]Segment{{Encoding: SegmentROT13, Offset: 0, Source: "ohl pbecben", Text: "buy corpora"}}},
+		{"notRequested", "Y29ycG9yYQ==", []SegmentEncoding{SegmentHex}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, WithSegmentDecoding(tt.encodings...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Segments(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Segments() = %v, want %v", got, tt.want)
+			}
+			if d.Text != tt.text {
+				t.Errorf("Text = %q, want %q", d.Text, tt.text)
+			}
+		})
+	}
+	for _, e := range lineEncodings {
+		if _, err := NewDocument("", WithSegmentDecoding(SegmentBase64, e)); err == nil {
+			t.Errorf("WithSegmentDecoding(%s) error = nil", e)
+		}
+	}
+}
+
+func TestWithSegmentDecoding_Find(t *testing.T) {
+	d, err := NewDocument("compre aqui Q09DQcONTkE=", WithSegmentDecoding(SegmentBase64, SegmentROT13),
+		WithSetLower(), WithTransform(NewASCII()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []Documenter
+	for _, p := range []string{"compre", "cocaina", "pbzcer"} {
+		pattern, err := NewDocument(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, pattern)
+	}
+	segments := d.Segments()
+	want := []Match{
+		{Index: 0, Sequence: []rune("compre"), Start: 0, End: 1, Score: 100},
+		{Index: 1, Sequence: []rune("cocaina"), Start: 0, End: 1, Segment: &segments[0], Score: 100},
+		{Index: 2, Sequence: []rune("pbzcer"), Start: 0, End: 1, Segment: &segments[1], Score: 100},
+	}
+	got := d.Find(docs...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+	if p := got[1].Provenance(); p != "found in base64 segment at offset 12" {
+		t.Errorf("Provenance() = %v", p)
+	}
+	if p := got[0].Provenance(); p != "found in text" {
+		t.Errorf("Provenance() = %v", p)
+	}
+	if got := d.Scan(docs...); !reflect.DeepEqual(got, Matches{0: []rune("compre"), 1: []rune("cocaina"),
+		2: []rune("pbzcer")}) {
+		t.Errorf("Scan() = %v", got)
+	}
+}
+
+func TestWithSegmentDecoding_Signals(t *testing.T) {
+	text := "&lt;compre&gt; 0YHQvtGB0LDRlm7QsA=="
+	d, err := NewDocument(text, WithEntityDecoding(), WithSegmentDecoding(SegmentBase64),
+		WithMixedScriptDetection(), WithOffsetTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := d.Segments()
+	if len(segments) != 1 || !strings.HasPrefix(text[segments[0].Offset:], segments[0].Source) {
+		t.Fatalf("Segments() = %v", segments)
+	}
+	want := []Signal{{Kind: SignalMixedScript, Offset: 0, Text: "сосаіnа", Detail: "Cyrillic+Latin",
+		Segment: &segments[0]}}
+	if got := d.Signals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Signals() = %v, want %v", got, want)
+	}
+	if got := d.Signals()[0].String(); got != `mixed script at offset 0 of the base64 segment at offset 15: "сосаіnа" Cyrillic+Latin` {
+		t.Errorf("String() = %v", got)
+	}
+}
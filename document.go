@@ -2,9 +2,9 @@ package gomtch
 
 import (
 	"fmt"
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/transform"
 	"io"
-	"io/ioutil"
 	"strings"
 	"unicode"
 )
@@ -15,6 +15,9 @@ var (
 	numericalInfo = []rune("%xª°º")
 )
 
+// Documenter is a text looked for by Scan and Find. It compares the tokens of the text scanned to
+// its own, so the options deciding how the runes match (ex: WithCaseInsensitiveMatching) are given
+// to it, while the normalization options are given to both texts.
 type Documenter interface {
 	Compare(ref Tokens) (bool, []rune)
 	IsEqual(a, b []rune) bool
@@ -28,301 +31,635 @@ type Scanner interface {
 
 type Document struct {
 	matchScoreFunc func(int, int) bool
-	transformer    transform.Transformer
-	optError       error
-	Text           string
-	Tokens         []string
+	// caseInsensitive makes the letters match in any case
+	caseInsensitive bool
+	runePolicy      *RunePolicy
+	scorer          Scorer
+	// matchScoreOptions are the names of the options given deciding whether the tokens match
+	matchScoreOptions []string
+	phrase            *PhraseScore
+	rewriteRules      []RewriteRule
+	rewriter          *strings.Replacer
+	// minimumScore returns the score the tokens of the given length found by the strategies must reach,
+	// if the option given decides it by a minimum or none was given
+	minimumScore func(int) int
+	// refRewriter rewrites the tokens of the Document, rewrittenRefs caches them rewritten
+	refRewriter   *strings.Replacer
+	rewrittenRefs rewrittenRefs
+	// vowelDropping and scrambledInterior are the matching strategies given, if any
+	vowelDropping     *Strategy
+	scrambledInterior *Strategy
+	flips             []Flip
+	transformer       transform.Transformer
+	optError          error
+	steps             []step
+	customOrder       bool
+	warnings          []Warning
+	signals           []Signal
+	removals          []Removal
+	htmlPieces        []HTMLPiece
+	markupSegments    []MarkupSegment
+	links             []MarkupLink
+	stringForm        StringForm
+	// charset and encoding are the charset the text is read with, declared or detected
+	charset       string
+	encoding      encoding.Encoding
+	detectCharset bool
+	strictUTF8    bool
+	segments      []Segment
+	// segmentTokens are the mapped tokens of each one of the segments, normalized on their own
+	segmentTokens []Tokens
+	// segmentSpans are the Spans of the tokens of each one of the segments read from the lines
+	segmentSpans [][]Span
+	// scriptSegmentation splits the scripts written without spaces after the tokenizer
+	scriptSegmentation bool
+	// original is the text given, before the normalization, offsetTracking maps the normalized text to it
+	original       string
+	offsetTracking bool
+	// offsets map the offsets of offsetsText, the text being normalized, to the ones of the original
+	offsets     offsetMap
+	offsetsText string
+	// positions are the positions of the Tokens in the normalized Text
+	positions []tokenPosition
+	// mapped, runes and joined cache the Tokens decoded when the Document is created,
+	// cachedTokens are the Tokens they were decoded from
+	mapped       Tokens
+	runes        [][]rune
+	joined       []rune
+	cachedTokens []string
+	Text         string
+	Tokens       []string
 }
 
 type Matches map[int][]rune
 
+// Locator is implemented by the Documenters able to tell where they were found.
+type Locator interface {
+	Locate(tokens Tokens) (Match, bool)
+}
+
+// Match describes a Documenter found in a Document. Start and End are the positions of the first
+// token found and after the last one in the Mapped tokens of the Document, or of the Segment
+// the Documenter was found in, if any. Score is the score of the tokens found, from 0 to 100: the
+// percentage of the runes matched or the scores of the Scorer of the Documenter, averaged by the
+// length of its tokens or the Weights of its PhraseScore. Technique is the least reliable way a token
+// was found and Penalty the score lost by the strategies used to find the tokens. Flip tells how
+// the tokens were written if they were found WithFlippedText. The Documenters that are not a Locator
+// only tell the Index and the Sequence of their Match.
+type Match struct {
+	Index     int
+	Sequence  []rune
+	Start     int
+	End       int
+	Segment   *Segment
+	Score     int
+	Technique Technique
+	Penalty   int
+	Flip      Flip
+}
+
 func NewDocument(text string, opts ...Option) (*Document, error) {
-	d := &Document{
-		Text: text,
-	}
-	d.applyOptions(opts...)
-	if d.optError != nil {
-		return nil, d.optError
-	}
-	return d, nil
+	return NewNormalizer(opts...).Document(text)
 }
 
 func NewDocumentFromReader(text io.Reader, opts ...Option) (*Document, error) {
-	b, err := ioutil.ReadAll(text)
-	if err != nil {
-		return nil, err
-	}
-	d := &Document{
-		Text: string(b),
-	}
-	d.applyOptions(opts...)
-	if d.optError != nil {
-		return nil, d.optError
-	}
-	return d, nil
+	return NewNormalizer(opts...).DocumentFromReader(text)
 }
 
-func (d *Document) applyOptions(opts ...Option) {
-	// Loop through each option
-	for _, opt := range opts {
-		// Call the option giving the instantiated
-		// *Document as the argument
-		opt(d)
-		if d.optError != nil {
-			return
-		}
-	}
-	if d.Tokens == nil {
-		d.Tokens = strings.Split(d.Text, " ")
-	}
-	if d.matchScoreFunc == nil {
-		WithMinimumMatchScore(100)(d)
-	}
+// Warnings returns the conflicts found between the options given to the Document.
+func (d Document) Warnings() []Warning {
+	return d.warnings
 }
 
+// String returns the normalized text or the original one if the Document was created
+// WithStringForm(StringOriginal).
 func (d Document) String() string {
+	if d.stringForm == StringOriginal {
+		return d.Original()
+	}
 	return d.Text
 }
 
 // CompareRune compares one rune to another and returns true if there is a match.
 // Besides checking equality by the standard form (==) it also applies some rules
 // to check if the compared value might be the same as the reference but is masked somehow.
-// Numbers and numerical info must match exactly. Also if both A and B are letters both
-// should match as well. If the compared entities is not a letter, number or numerical info and
-// the reference is not a number or numerical info, it will match.
+// The rules are the RunePolicy of the Document, DefaultRunePolicy if none was given:
+// numbers and numerical info must match exactly and if the compared entity is not a letter,
+// number or numerical info and the reference is not a number or numerical info, it will match.
+// Letters in a different case match if the Document was created WithCaseInsensitiveMatching.
 func (d Document) CompareRune(a, b rune) bool {
-	if unicode.IsNumber(a) || isNumericalInfo(a) {
-		return a == b
+	if d.equalRune(a, b) {
+		return true
 	}
-	if unicode.IsLetter(a) && unicode.IsLetter(b) {
-		return a == b
-	}
-	if unicode.IsNumber(b) || isNumericalInfo(b) {
-		return false
-	}
-	if unicode.IsLetter(a) && !unicode.IsLetter(b) {
-		return false
-	}
-	return true
+	return d.policy().masks(a, b, true)
 }
 
 // IsEqual compares A and B and returns true if they probably are the same word and false otherwise.
 // if A and B have different lengths it will return false.
 // The A and B variables are not interchangeable as A represents the entities to be compared to B, the reference.
 // IsEqual uses the minimumMatchScore to determine if the words are the same even if there are differences
-// between then.
-// Numbers, numericalInfo and letters should match exactly and the matches increase the counter for
-// the minimumMatchScore, otherwise it returns false immediately.
+// between then, or the Scorer of the Document if it was created WithScorer.
+// The runes of A that are not equal to the ones of B must be wildcards of the RunePolicy, they
+// do not increase the counter for the minimumMatchScore. Otherwise it returns false immediately.
 func (d Document) IsEqual(a, b []rune) bool {
+	return d.isEqual(a, b, -1, CheckToken)
+}
+
+// isEqual is IsEqual telling the Scorer, if any, which token of the pattern is compared and how.
+func (d Document) isEqual(a, b []rune, position int, path CheckPath) bool {
+	_, ok := d.matchRunes(a, b, position, path)
+	return ok
+}
+
+// matchRunes compares the runes and returns the Technique that found them equal. The runes
+// that do not match are compared again after the rewrite rules and with the strategies of the
+// Document, if any.
+func (d Document) matchRunes(a, b []rune, position int, path CheckPath) (Technique, bool) {
+	if d.compareRunes(a, b, position, path) {
+		return TechniqueDirect, true
+	}
+	if d.rewriter != nil {
+		ra, changedA := d.rewrite(a)
+		rb, changedB := d.rewriteRef(b)
+		if (changedA || changedB) && d.compareRunes(ra, rb, position, path) {
+			return TechniqueRewrite, true
+		}
+	}
+	return d.matchStrategies(a, b)
+}
+
+// compareRunes compares the runes as isEqual does, without the rewrite rules.
+func (d Document) compareRunes(a, b []rune, position int, path CheckPath) bool {
 	if len(a) == 1 && len(b) == 1 {
-		return d.CompareRune(a[0], b[0])
+		if !d.CompareRune(a[0], b[0]) {
+			return false
+		}
+		if d.scorer != nil || d.phrase != nil {
+			_, ok := d.score(a, b, position, CheckRune)
+			return ok
+		}
+		return true
 	}
 	if len(a) != len(b) {
 		return false
 	}
-	var matchScore int
+	policy := d.policy()
+	var matchScore, wildcards int
 	for i, v := range b {
-		if v != a[i] {
-			// A word made solely of numbers or number related points (%ª°x) can pass only
-			// if all points match.
-			if !unicode.IsNumber(v) && !isNumericalInfo(v) {
-				// if the reference point is not a number or numerical info and its type (letter)
-				// does not match the type expected for that point index than the words are not the same.
-				if unicode.IsLetter(v) == unicode.IsLetter(a[i]) {
-					return false
-				}
-				continue
-			}
-			// comparison rules could be added here
-			// for now lets keep it simple
+		if d.equalRune(v, a[i]) {
+			matchScore++
+			continue
+		}
+		if !policy.masks(a[i], v, false) {
+			return false
+		}
+		wildcards++
+		if policy.MaxWildcards > 0 && wildcards > policy.MaxWildcards {
 			return false
 		}
-		matchScore++
+	}
+	if d.scorer != nil || d.phrase != nil {
+		_, ok := d.score(a, b, position, path)
+		return ok
 	}
 	return d.matchScoreFunc(matchScore, len(b))
 }
 
+// equalRune reports whether a and b are the same rune, ignoring the case
+// if the Document was created WithCaseInsensitiveMatching.
+func (d Document) equalRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	if !d.caseInsensitive {
+		return false
+	}
+	// the runes equivalent under simple case folding form a cycle
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+// foldedRune returns the same rune for all the runes equivalent under simple case folding
+// (ex: K, k and the Kelvin sign K), the smallest of them.
+func foldedRune(r rune) rune {
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+	return folded
+}
+
+// Scan compares each one of the docs to the Document and returns the sequences found by their index.
+// The docs not found in the text are looked for in the Segments decoded from it. The docs are the ones
+// comparing the runes, so the matching options of the Document scanned are not used.
 func (d Document) Scan(docs ...Documenter) Matches {
 	matches := map[int][]rune{}
-	tokens := NewMappingFromTokens(d.Tokens).Map()
+	tokens := d.Mapped()
 	for i, doc := range docs {
 		if ok, sequence := doc.Compare(tokens); ok {
 			matches[i] = sequence
+			continue
+		}
+		for _, segment := range d.segmentTokens {
+			if ok, sequence := doc.Compare(segment); ok {
+				matches[i] = sequence
+				break
+			}
 		}
 	}
 	return matches
 }
 
-type compareEntity struct {
-	found    bool
-	sequence []rune
-	at       int
-	last     int
+// Find looks for each one of the docs in the Document and returns a Match for each one found,
+// in the order the docs were given. Docs that are not a Locator are looked for with Compare and
+// their Match has no position. The docs not found in the text are looked for in the Segments
+// decoded from it and their Match tells the Segment.
+func (d Document) Find(docs ...Documenter) []Match {
+	var matches []Match
+	tokens := d.Mapped()
+	for i, doc := range docs {
+		m, ok := find(doc, tokens)
+		for j := 0; !ok && j < len(d.segmentTokens); j++ {
+			if m, ok = find(doc, d.segmentTokens[j]); ok {
+				m.Segment = &d.segments[j]
+			}
+		}
+		if ok {
+			m.Index = i
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+func find(doc Documenter, tokens Tokens) (Match, bool) {
+	if l, isLocator := doc.(Locator); isLocator {
+		return l.Locate(tokens)
+	}
+	var m Match
+	var ok bool
+	ok, m.Sequence = doc.Compare(tokens)
+	m.Start, m.End = -1, -1
+	return m, ok
+}
+
+// Mapped returns the tokens of the Document with the special characters split from the words,
+// as given to Compare. The positions of a Match refer to them.
+func (d Document) Mapped() Tokens {
+	if !d.cacheValid() {
+		return NewTokens(d.Tokens)
+	}
+	return d.mapped
+}
+
+// cache decodes the Tokens of the Document once so Mapped and Compare do not decode them again.
+func (d *Document) cache() {
+	d.mapped = NewTokens(d.Tokens)
+	d.runes, d.joined = decodeTokens(d.Tokens)
+	d.cachedTokens = append([]string(nil), d.Tokens...)
+	d.positions = locateTokens(d.Text, d.Tokens)
+	if d.refRewriter != nil {
+		d.rewrittenRefs = newRewrittenRefs(d.refRewriter, append(append([][]rune(nil), d.runes...), d.joined)...)
+	}
+}
+
+// cacheValid reports whether the cached tokens are the Tokens of the Document. They are not if
+// the Document was not built by a Normalizer or if its Tokens were changed after it was.
+func (d Document) cacheValid() bool {
+	if d.cachedTokens == nil || len(d.cachedTokens) != len(d.Tokens) {
+		return false
+	}
+	for i, t := range d.Tokens {
+		if t != d.cachedTokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeTokens returns the runes of each token and the runes of all the tokens joined.
+func decodeTokens(tokens []string) ([][]rune, []rune) {
+	runes := make([][]rune, len(tokens))
+	var joined []rune
+	for i, t := range tokens {
+		runes[i] = []rune(t)
+		joined = append(joined, runes[i]...)
+	}
+	return runes, joined
 }
 
+// maxStackPositions is the number of pattern tokens Compare tracks without allocating.
+const maxStackPositions = 16
+
 func (d Document) Compare(tokens Tokens) (bool, []rune) {
-	ce := compareEntity{}
-	var sequence []rune
-	for _, ref := range d.Tokens {
-		ce.found, sequence, ce.at = d.simpleCheck([]rune(ref), tokens, ce.at)
-		if !ce.found {
+	m, ok := d.search(tokens, false)
+	return ok, m.Sequence
+}
+
+// Locate looks for the Document in the tokens the same way Compare does and
+// returns the Match describing where it was found.
+func (d Document) Locate(tokens Tokens) (Match, bool) {
+	return d.search(tokens, true)
+}
+
+// search looks for the Document in the tokens and then in the tokens flipped, if any. The Score,
+// the Technique and the Penalty of the Match are only set if describe is.
+func (d Document) search(tokens Tokens, describe bool) (Match, bool) {
+	m, ok := d.locate(tokens, describe)
+	if ok || d.flips == nil {
+		return m, ok
+	}
+	return d.locateFlipped(tokens, describe)
+}
+
+func (d Document) locate(tokens Tokens, describe bool) (Match, bool) {
+	refs, joined := d.runes, d.joined
+	if !d.cacheValid() {
+		refs, joined = decodeTokens(d.Tokens)
+	}
+	var stack [maxStackPositions]int
+	positions := stack[:0]
+	var found bool
+	var at, last int
+	for i, ref := range refs {
+		var position int
+		found, position = d.simpleCheck(ref, i, tokens, at)
+		if !found {
 			break
 		}
-		if ce.at != ce.last {
-			ce.last = ce.at
-			ce.at++
+		positions = append(positions, position)
+		at = position
+		if at != last {
+			last = at
+			at++
 		}
-		if ce.at == 0 {
-			ce.at++
+		if at == 0 {
+			at++
 		}
-
-		if ce.sequence == nil {
-			ce.sequence = sequence
-		} else {
-			ce.sequence = append(ce.sequence, whiteSpace)
-			ce.sequence = append(ce.sequence, sequence...)
+	}
+	if found && d.phrase != nil {
+		found = d.phraseMatches(refs, tokens, positions)
+		if !found {
+			// the joined tokens may still reach the minimum
+			positions = positions[:0]
 		}
 	}
-	if !ce.found {
-		ce.found, sequence = d.specialCheck([]rune(strings.Join(d.Tokens, "")), tokens)
-		if !ce.found {
-			return false, nil
+	if found {
+		m := Match{
+			Sequence: joinTokens(tokens, positions, nil),
+			Start:    positions[0],
+			End:      positions[len(positions)-1] + 1,
 		}
-		if ce.sequence == nil {
-			ce.sequence = sequence
-		} else {
-			ce.sequence = append(ce.sequence, whiteSpace)
-			ce.sequence = append(ce.sequence, sequence...)
+		if describe {
+			d.describe(&m, refs, tokens, positions, nil, nil)
 		}
+		return m, true
+	}
+	found, special, start, end := d.specialCheck(joined, tokens)
+	if !found {
+		return Match{}, false
 	}
-	return true, ce.sequence
+	// the joined refs are the whole Document, the tokens found before are not part of the Match
+	m := Match{
+		Sequence: special,
+		Start:    start,
+		End:      end,
+	}
+	if describe {
+		d.describe(&m, refs, tokens, nil, withoutSpaces(special), joined)
+	}
+	return m, true
 }
 
-func isSpecial(r rune) bool {
-	if unicode.IsNumber(r) || unicode.IsLetter(r) || isNumericalInfo(r) {
-		return false
+// describe sets the Score, the Technique and the Penalty of the Match from the tokens found at the
+// positions, each one of them matching the token of the refs at the same index, and the joined runes
+// found matching the joined refs, if any.
+func (d Document) describe(m *Match, refs [][]rune, tokens Tokens, positions []int, found, joined []rune) {
+	var s matchScore
+	d.describeTokens(m, &s, 0, refs, tokens, positions, found, joined)
+	m.Score = s.value()
+}
+
+// matchScore is the average of the scores of the tokens of a Match, by their weights.
+type matchScore struct {
+	total, weights float64
+}
+
+func (s *matchScore) add(score, weight float64) {
+	s.total += weight * score
+	s.weights += weight
+}
+
+func (s matchScore) value() int {
+	if s.weights == 0 {
+		return 0
 	}
-	return true
+	return int(s.total/s.weights + 1e-9)
 }
 
-func (d Document) simpleCheck(value []rune, tokens Tokens, start int) (bool, []rune, int) {
-	for i, id := range tokens.Ids {
-		if start == 0 {
-			if d.IsEqual(tokens.GetRunesByID(id), value) {
-				return true, tokens.GetRunesByID(id), i
-			}
-			continue
+// describeTokens sets the Technique and the Penalty of the Match and adds the scores of the tokens as
+// describe does. index is the index of the first one of the refs in the tokens of the Document.
+func (d Document) describeTokens(m *Match, s *matchScore, index int, refs [][]rune, tokens Tokens, positions []int,
+	found, joined []rune) {
+	add := func(a, b []rune, weight float64, position int, path CheckPath) {
+		t := TechniqueDirect
+		if d.rewriter != nil || d.vowelDropping != nil || d.scrambledInterior != nil {
+			t, _ = d.matchRunes(a, b, position, path)
 		}
-		if i < start {
-			continue
+		penalty := d.penalty(t)
+		m.Penalty += penalty
+		if t != TechniqueDirect && (m.Technique == TechniqueDirect || penalty > d.penalty(m.Technique)) {
+			m.Technique = t
 		}
-		if d.IsEqual(tokens.GetRunesByID(id), value) {
-			return true, tokens.GetRunesByID(id), i
-		} else {
-			return false, nil, i
+		s.add(d.techniqueScore(t, a, b, position, path), weight)
+	}
+	for i, p := range positions {
+		a, path := tokens.GetRunesByID(tokens.Ids[p]), CheckToken
+		if len(a) == 1 && len(refs[i]) == 1 {
+			path = CheckRune
 		}
+		weight := float64(len(refs[i]))
+		if d.phrase != nil {
+			weight = d.phrase.weight(index+i, len(refs[i]))
+		}
+		add(a, refs[i], weight, index+i, path)
+	}
+	if found != nil {
+		add(found, joined, float64(len(joined)), -1, CheckJoined)
 	}
-	return false, nil, 0
 }
 
-type specialCheck struct {
-	startAt            int
-	ref                []rune
-	compare            []rune
-	matchOnOneSpecial  int
-	lastFound          bool
-	cntr               int
-	completeWordSpaced []rune
-	completeWord       []rune
+// techniqueScore returns the score of the runes a of the text found matching the runes b of the
+// pattern with the technique. The strategies score the runes they found as matched, less their Penalty.
+func (d Document) techniqueScore(t Technique, a, b []rune, position int, path CheckPath) float64 {
+	switch t {
+	case TechniqueDirect:
+	case TechniqueRewrite:
+		a, _ = d.rewrite(a)
+		b, _ = d.rewriteRef(b)
+	default:
+		return float64(strategyScore(d.penalty(t)))
+	}
+	if len(a) != len(b) {
+		return 100
+	}
+	score, _ := d.score(a, b, position, path)
+	return score
 }
 
-func newSpecialCheck() *specialCheck {
-	return &specialCheck{
-		cntr: 1,
+// withoutSpaces returns the runes without the white spaces separating the tokens.
+func withoutSpaces(runes []rune) []rune {
+	var r []rune
+	for _, c := range runes {
+		if c != whiteSpace {
+			r = append(r, c)
+		}
 	}
+	return r
 }
 
-func (sc *specialCheck) appendCompleteWord(new []rune) {
-	if len(sc.completeWordSpaced) == 0 {
-		sc.completeWordSpaced = new
-		sc.completeWord = new
-		return
+// joinTokens returns the runes of the tokens at the given positions separated by white spaces,
+// followed by the extra runes.
+func joinTokens(tokens Tokens, positions []int, extra []rune) []rune {
+	size := len(positions) + len(extra)
+	for _, p := range positions {
+		size += len(tokens.GetRunesByID(tokens.Ids[p]))
 	}
-	sc.completeWordSpaced = append(sc.completeWordSpaced, whiteSpace)
-	sc.completeWordSpaced = append(sc.completeWordSpaced, new...)
-	sc.completeWord = append(sc.completeWord, new...)
+	sequence := make([]rune, 0, size)
+	for i, p := range positions {
+		if i != 0 {
+			sequence = append(sequence, whiteSpace)
+		}
+		sequence = append(sequence, tokens.GetRunesByID(tokens.Ids[p])...)
+	}
+	if extra != nil {
+		if len(positions) != 0 {
+			sequence = append(sequence, whiteSpace)
+		}
+		sequence = append(sequence, extra...)
+	}
+	return sequence
 }
 
-func (d Document) specialCheck(value []rune, tokens Tokens) (bool, []rune) {
-	sc := newSpecialCheck()
-	for _, id := range tokens.Ids {
+func isSpecial(r rune) bool {
+	return classOf(r).isSpecial()
+}
+
+// simpleCheck looks for the value in the tokens. If start is zero the value can be anywhere,
+// otherwise it must be exactly at the start position. It returns the position found.
+// index is the position of the value in the tokens of the Document.
+func (d Document) simpleCheck(value []rune, index int, tokens Tokens, start int) (bool, int) {
+	if start == 0 {
+		for i, id := range tokens.Ids {
+			if d.isEqual(tokens.GetRunesByID(id), value, index, CheckToken) {
+				return true, i
+			}
+		}
+		return false, 0
+	}
+	if start < len(tokens.Ids) && d.isEqual(tokens.GetRunesByID(tokens.Ids[start]), value, index, CheckToken) {
+		return true, start
+	}
+	return false, 0
+}
+
+// maxStackWord is the number of runes specialCheck accumulates without allocating.
+const maxStackWord = 64
+
+// dropFront removes the first n runes of the word keeping its capacity.
+func dropFront(word []rune, n int) []rune {
+	return word[:copy(word, word[n:])]
+}
+
+// specialCheck looks for the value split in many sequential tokens. The returned runes are the
+// tokens found separated by white spaces, the only allocation is made for them when they are found.
+// The positions of the first and after the last token found are returned as well.
+func (d Document) specialCheck(value []rune, tokens Tokens) (bool, []rune, int, int) {
+	if len(value) == 0 {
+		return false, nil, 0, 0
+	}
+	var spacedBuf, wordBuf [maxStackWord]rune
+	completeWordSpaced, completeWord := spacedBuf[:0], wordBuf[:0]
+	var startAt, matchOnOneSpecial, first int
+	cntr := 1
+	for position, id := range tokens.Ids {
 	Outer:
 		for {
-			for i := 0; i < len(value); i++ {
-				if i < sc.startAt {
-					continue
-				}
-				sc.ref = value[i : i+sc.cntr]
-				sc.compare = tokens.GetRunesByID(id)
-				if len(sc.ref) != len(sc.compare) {
-					if i+sc.cntr == len(value) {
-						sc.lastFound = false
-						sc.startAt = 0
-						sc.cntr = 1
+			for i := startAt; i < len(value); i++ {
+				ref := value[i : i+cntr]
+				compare := tokens.GetRunesByID(id)
+				if len(ref) != len(compare) {
+					if i+cntr == len(value) {
+						startAt = 0
+						cntr = 1
 						break Outer
 					}
-					sc.cntr++
+					cntr++
 					break
 				}
-				if !d.IsEqual(sc.compare, sc.ref) {
-					sc.lastFound = false
-					sc.startAt = 0
-					sc.cntr = 1
-					if sc.matchOnOneSpecial != 0 {
-						sc.completeWordSpaced = sc.completeWordSpaced[sc.matchOnOneSpecial:]
-						sc.completeWord = sc.completeWord[sc.matchOnOneSpecial:]
-						sc.matchOnOneSpecial = 0
+				if !d.isEqual(compare, ref, -1, CheckJoined) {
+					// a token that breaks a partial match may still start a new one
+					retry := startAt != 0
+					startAt = 0
+					cntr = 1
+					if matchOnOneSpecial != 0 {
+						completeWordSpaced = dropFront(completeWordSpaced, matchOnOneSpecial)
+						completeWord = dropFront(completeWord, matchOnOneSpecial)
+						first += matchOnOneSpecial
+						matchOnOneSpecial = 0
+						continue Outer
+					}
+					completeWordSpaced = completeWordSpaced[:0]
+					completeWord = completeWord[:0]
+					if retry {
 						continue Outer
 					}
-					sc.completeWordSpaced = nil
-					sc.completeWord = nil
 					break Outer
 				}
-				sc.appendCompleteWord(sc.compare)
-				sc.lastFound = true
-				if len(sc.ref) == 1 && sc.startAt == 0 {
-					if isSpecial(sc.compare[0]) {
-						sc.matchOnOneSpecial++
+				if len(completeWordSpaced) != 0 {
+					completeWordSpaced = append(completeWordSpaced, whiteSpace)
+				} else {
+					completeWord = completeWord[:0]
+					first = position
+				}
+				completeWordSpaced = append(completeWordSpaced, compare...)
+				completeWord = append(completeWord, compare...)
+				if len(ref) == 1 && startAt == 0 {
+					if isSpecial(compare[0]) {
+						matchOnOneSpecial++
 					}
 				} else {
-					if sc.matchOnOneSpecial != 0 {
-						if isSpecial(sc.compare[0]) {
-							sc.matchOnOneSpecial++
+					if matchOnOneSpecial != 0 {
+						if isSpecial(compare[0]) {
+							matchOnOneSpecial++
 						} else {
-							sc.matchOnOneSpecial = 0
+							matchOnOneSpecial = 0
 						}
 					}
 				}
-				sc.startAt = i + sc.cntr
-				if sc.startAt == len(value) {
-					if !d.IsEqual(sc.completeWord, value) {
-						return false, nil
+				startAt = i + cntr
+				if startAt == len(value) {
+					if !d.isEqual(completeWord, value, -1, CheckJoined) || !d.joinedMatches(completeWord, value) {
+						return false, nil, 0, 0
 					}
-					return true, sc.completeWordSpaced
+					sequence := make([]rune, len(completeWordSpaced))
+					copy(sequence, completeWordSpaced)
+					return true, sequence, first, position + 1
 				}
-				sc.cntr = 1
+				cntr = 1
 				break Outer
 			}
 		}
 	}
-	return false, nil
+	return false, nil, 0, 0
 }
 
 func isNumericalInfo(v rune) bool {
-	for _, r := range numericalInfo {
-		if v == r {
-			return true
-		}
-	}
-	return false
+	return classOf(v)&classNumericalInfo != 0
 }
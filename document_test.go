@@ -3,6 +3,7 @@ package gomtch
 import (
 	"fmt"
 	"github.com/jdkato/prose/tokenize"
+	"golang.org/x/text/language"
 	"golang.org/x/text/transform"
 	"io"
 	"io/ioutil"
@@ -80,7 +81,7 @@ func TestNewDocFromReader(t *testing.T) {
 			opts: []Option{WithReplacer(regexp.MustCompile(`[()-]`), " ")}},
 			&Document{
 				Text:   "coca  cocaína  para compra venda",
-				Tokens: []string{"coca", "", "cocaína", "", "para", "compra", "venda"},
+				Tokens: []string{"coca", "cocaína", "para", "compra", "venda"},
 			}, false},
 		{"withCustomRegexpTokenizer", args{
 			text: strings.NewReader("coca cocaína para compra venda"),
@@ -89,6 +90,60 @@ func TestNewDocFromReader(t *testing.T) {
 				Text:   "coca cocaína para compra venda",
 				Tokens: []string{"coca", "cocaína", "para", "compra", "venda"},
 			}, false},
+		{"defaultTokenizerTabsAndNewLines", args{
+			text: strings.NewReader("coca\tcocaína\npara\u00a0compra venda"),
+			opts: []Option{}},
+			&Document{
+				Text:   "coca\tcocaína\npara\u00a0compra venda",
+				Tokens: []string{"coca", "cocaína", "para", "compra", "venda"},
+			}, false},
+		{"withTokenizer", args{
+			text: strings.NewReader("un!lever d'água"),
+			opts: []Option{WithTokenizer(NewWordTokenizer())}},
+			&Document{
+				Text:   "un!lever d'água",
+				Tokens: []string{"un", "!", "lever", "d'água"},
+			}, false},
+		{"canonicalOrder", args{
+			text: strings.NewReader("<p>COCAÍNA</p>"),
+			opts: []Option{
+				WithReplacer(regexp.MustCompile(`cocaina`), "coca"),
+				WithTransform(NewASCII()),
+				WithSetLower(),
+				WithHMTLParsing(),
+			}},
+			&Document{
+				Text:   "coca",
+				Tokens: []string{"coca"},
+			}, false},
+		{"sequentialEqualCharsRemovalBeforeHTML", args{
+			text: strings.NewReader("<b>ca</b><b>asa</b>"),
+			opts: []Option{WithSequentialEqualCharsRemoval(), WithHMTLParsing()}},
+			&Document{
+				Text:   "casa",
+				Tokens: []string{"casa"},
+			}, false},
+		{"withCustomOrder", args{
+			text: strings.NewReader("cocaína"),
+			opts: []Option{
+				WithCustomOrder(),
+				WithReplacer(regexp.MustCompile(`cocaina`), "coca"),
+				WithTransform(NewASCII()),
+			}},
+			&Document{
+				Text:   "cocaina",
+				Tokens: []string{"cocaina"},
+			}, false},
+		{"withStage", args{
+			text: strings.NewReader("cocaína"),
+			opts: []Option{
+				WithStage(StageDecode, WithReplacer(regexp.MustCompile(`í`), "i")),
+				WithReplacer(regexp.MustCompile(`cocaina`), "coca"),
+			}},
+			&Document{
+				Text:   "coca",
+				Tokens: []string{"coca"},
+			}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -111,6 +166,105 @@ func TestNewDocFromReader(t *testing.T) {
 	}
 }
 
+func TestDocument_Warnings(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []Warning
+	}{
+		{"noConflict", []Option{WithSetLower(), WithTransform(NewASCII())}, nil},
+		{"lowerAndUpper", []Option{WithSetLower(), WithSetUpper()}, []Warning{{
+			Options: []string{"WithSetLower", "WithSetUpper"},
+			Message: "more than one case folding option given, only WithSetUpper takes effect",
+		}}},
+		{"customOrderOutOfStage", []Option{WithCustomOrder(), WithTransform(NewASCII()), WithHMTLParsing()},
+			[]Warning{{
+				Options: []string{"WithTransform", "WithHMTLParsing"},
+				Message: "markup extraction runs after unicode folding",
+			}}},
+		{"stagedOptionName", []Option{WithStage(StageCaseFolding, WithTransform(NewASCII())), WithSetUpper()},
+			[]Warning{{
+				Options: []string{"WithTransform", "WithSetUpper"},
+				Message: "more than one case folding option given, only WithSetUpper takes effect",
+			}}},
+		{"customOptionName", []Option{WithStage(StageCaseFolding, upperCase), WithSetLower()},
+			[]Warning{{
+				Options: []string{"gomtch.upperCase", "WithSetLower"},
+				Message: "more than one case folding option given, only WithSetLower takes effect",
+			}}},
+		{"matchScore", []Option{WithFuzziness(FuzzinessAuto), WithMinimumMatchScore(90)},
+			[]Warning{{
+				Options: []string{"WithFuzziness", "WithMinimumMatchScore"},
+				Message: "more than one match score option given, only WithMinimumMatchScore takes effect",
+			}}},
+		{"scorer", []Option{WithMinimumMatchScore(90), WithScorer(NewMinimumScorer(60))},
+			[]Warning{{
+				Options: []string{"WithMinimumMatchScore", "WithScorer"},
+				Message: "more than one match score option given, only WithScorer takes effect",
+			}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument("Cocaína", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Warnings(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Warnings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// locateIn creates the Documents of the text and of the pattern, with the options, and locates
+// the pattern in the text.
+func locateIn(t *testing.T, text, pattern string, opts ...Option) (Match, bool) {
+	t.Helper()
+	p, err := NewDocument(pattern, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDocument(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Locate(d.Mapped())
+}
+
+func upperCase(d *Document) {
+	d.Text = strings.ToUpper(d.Text)
+}
+
+func TestStage_values(t *testing.T) {
+	// the stages run in the order of their values
+	stages := []Stage{StageDecode, StageMarkup, StageInvisibleRemoval, StageAnalysis, StageCompatibilityFolding,
+		StageCaseFolding, StageUnicodeFolding, StageCleanup, StageTokenization}
+	for i := 1; i < len(stages); i++ {
+		if stages[i] <= stages[i-1] {
+			t.Errorf("%s = %d, want more than %s = %d", stages[i], stages[i], stages[i-1], stages[i-1])
+		}
+	}
+}
+
+func TestWithStage_callsOnce(t *testing.T) {
+	calls := 0
+	replacer := WithReplacer(regexp.MustCompile(`í`), "i")
+	counted := func(d *Document) {
+		calls++
+		replacer(d)
+	}
+	d, err := NewDocument("cocaína", WithStage(StageDecode, counted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || d.Text != "cocaina" {
+		t.Errorf("option called %d times, text %q, want 1 and cocaina", calls, d.Text)
+	}
+	if _, err := NewDocument("", WithStage(StageDecode, WithLineReading(SegmentBase64))); err == nil {
+		t.Error("NewDocument() error = nil, want the error of the staged option")
+	}
+}
+
 func TestDoc_String(t *testing.T) {
 	type fields struct {
 		t        transform.Transformer
@@ -201,12 +355,87 @@ func TestDoc_CompareRune(t *testing.T) {
 	}
 }
 
+func TestDoc_CompareRuneCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b rune
+		want bool
+	}{
+		{"sameCase", 'a', 'a', true},
+		{"upperAndLower", 'A', 'a', true},
+		{"lowerAndUpper", 'ç', 'Ç', true},
+		{"differentLetters", 'A', 'b', false},
+		{"kelvinSign", 'K', 'k', true},
+		{"finalSigma", 'ς', 'Σ', true},
+		{"numbers", '1', '1', true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument("", WithCaseInsensitiveMatching())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.CompareRune(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareRune() = %v, want %v", got, tt.want)
+			}
+			if got := (Document{}).CompareRune(tt.a, tt.b); got != (tt.a == tt.b) {
+				t.Errorf("CompareRune() without WithCaseInsensitiveMatching = %v", got)
+			}
+		})
+	}
+}
+
+func TestWithCaseFolding(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  language.Tag
+		text string
+		want string
+	}{
+		{"default", language.Und, "COCAÍNA", "cocaína"},
+		{"ligature", language.Und, "STUﬀ", "stuff"},
+		{"sharpS", language.German, "STRAẞE Straße", "strasse strasse"},
+		{"greekFinalSigma", language.Greek, "ΟΔΟΣ οδος", "οδοσ οδοσ"},
+		{"turkish", language.Turkish, "İSTANBUL IĞDIR", "istanbul ığdır"},
+		{"notTurkish", language.English, "IĞDIR", "iğdir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, WithCaseFolding(tt.tag))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Text != tt.want {
+				t.Errorf("Text = %q, want %q", d.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCaseInsensitiveMatching(t *testing.T) {
+	d, err := NewDocument("compre COCAÍNA aqui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern, err := NewDocument("cocaína", WithCaseInsensitiveMatching())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Scan(pattern); !reflect.DeepEqual(got, Matches{0: []rune("COCAÍNA")}) {
+		t.Errorf("Scan() = %v", got)
+	}
+	if d.Text != "compre COCAÍNA aqui" {
+		t.Errorf("Text = %q", d.Text)
+	}
+}
+
 func TestDoc_IsSame(t *testing.T) {
 	type fields struct {
-		matchScoreFunc func(int, int) bool
-		t              transform.Transformer
-		optError       error
-		text           string
+		matchScoreFunc  func(int, int) bool
+		t               transform.Transformer
+		optError        error
+		text            string
+		caseInsensitive bool
 	}
 	type args struct {
 		a []rune
@@ -260,14 +489,27 @@ func TestDoc_IsSame(t *testing.T) {
 			a: []rune(":)a="),
 			b: []rune("anal"),
 		}, false},
+		{"differentCase", fields{matchScoreFunc: func(matchScore, wordLength int) bool {
+			return matchScore*100/wordLength >= 100
+		}}, args{
+			a: []rune("COCAINA"),
+			b: []rune("cocaina"),
+		}, false},
+		{"caseInsensitive", fields{matchScoreFunc: func(matchScore, wordLength int) bool {
+			return matchScore*100/wordLength >= 100
+		}, caseInsensitive: true}, args{
+			a: []rune("COCAINA"),
+			b: []rune("cocaina"),
+		}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			d := Document{
-				matchScoreFunc: tt.fields.matchScoreFunc,
-				transformer:    tt.fields.t,
-				optError:       tt.fields.optError,
-				Text:           tt.fields.text,
+				matchScoreFunc:  tt.fields.matchScoreFunc,
+				transformer:     tt.fields.t,
+				optError:        tt.fields.optError,
+				Text:            tt.fields.text,
+				caseInsensitive: tt.fields.caseInsensitive,
 			}
 			if got := d.IsEqual(tt.args.a, tt.args.b); got != tt.want {
 				t.Errorf("IsEqual() = %v, want %v", got, tt.want)
@@ -359,6 +601,34 @@ func TestDoc_Compare(t *testing.T) {
 			true,
 			[]rune("c o c a i n a b r a n c a"),
 		},
+		{"splitRepeatedStart", fields{
+			text: "cocaína",
+			opts: []Option{WithTransform(NewASCII()), WithMinimumMatchScore(60)}}, args{
+			tokens: NewMappingFromTokens([]string{"c", "c", "o", "c", "a", "i", "n", "a"}).Map()},
+			true,
+			[]rune("c o c a i n a"),
+		},
+		{"splitRepeatedSyllable", fields{
+			text: "cocaína",
+			opts: []Option{WithTransform(NewASCII()), WithMinimumMatchScore(60)}}, args{
+			tokens: NewMappingFromTokens([]string{"co", "co", "ca", "ina"}).Map()},
+			true,
+			[]rune("co ca ina"),
+		},
+		{"splitAfterWord", fields{
+			text: "sell cocaína",
+			opts: []Option{WithMinimumMatchScore(60)}}, args{
+			tokens: NewMappingFromTokens([]string{"sell", "c", "o", "c", "a", "í", "n", "a"}).Map()},
+			true,
+			[]rune("sell c o c a í n a"),
+		},
+		{"splitBroken", fields{
+			text: "cocaína",
+			opts: []Option{WithTransform(NewASCII()), WithMinimumMatchScore(60)}}, args{
+			tokens: NewMappingFromTokens([]string{"c", "o", "x", "c", "a", "i", "n", "a"}).Map()},
+			false,
+			nil,
+		},
 		{"un! lever", fields{
 			text: "unilever",
 			opts: []Option{WithTransform(NewASCII()), WithMinimumMatchScore(60)}}, args{
@@ -935,6 +1205,10 @@ func BenchmarkDoc_Scan(b *testing.B) {
 			if got := tt.doc.Scan(tt.args.docs...); !reflect.DeepEqual(got, tt.want) {
 				b.Errorf("Scan() = %v, want %v", got, tt.want)
 			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tt.doc.Scan(tt.args.docs...)
+			}
 		})
 	}
 }
@@ -952,3 +1226,104 @@ func matchScoreFunction(matchScore, wordLength int) bool {
 		return matchScore*100/wordLength >= 60
 	}
 }
+
+func BenchmarkDocument_Compare(b *testing.B) {
+	text, err := NewDocument(fmt.Sprintf("%s Un! le ver", strings.Join(sertoesTokens()[:5000], " ")),
+		WithTransform(NewASCII()), WithSetLower())
+	if err != nil {
+		log.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		allocs  float64
+	}{
+		{"noMatch", "natura", 0},
+		{"simpleMatch", "sertoes", 1},
+		{"specialMatch", "unilever", 2},
+	}
+	for _, tt := range tests {
+		pattern, err := NewDocument(tt.pattern, WithMinimumMatchScore(60))
+		if err != nil {
+			log.Fatal(err)
+		}
+		b.Run(tt.name, func(b *testing.B) {
+			allocs := testing.AllocsPerRun(10, func() {
+				pattern.Compare(text.mapped)
+			})
+			if allocs > tt.allocs {
+				b.Errorf("Compare() allocs = %v, want at most %v", allocs, tt.allocs)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				pattern.Compare(text.mapped)
+			}
+		})
+	}
+}
+
+func BenchmarkDocument_IsEqual(b *testing.B) {
+	d, err := NewDocument("cocaina", WithMinimumMatchScore(60))
+	if err != nil {
+		log.Fatal(err)
+	}
+	a, ref := []rune("c0c@ína"), []rune("cocaína")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d.IsEqual(a, ref)
+	}
+}
+
+// compareOnly is a Documenter that is not a Locator.
+type compareOnly struct {
+	d *Document
+}
+
+func (c compareOnly) Compare(ref Tokens) (bool, []rune) { return c.d.Compare(ref) }
+func (c compareOnly) IsEqual(a, b []rune) bool          { return c.d.IsEqual(a, b) }
+func (c compareOnly) CompareRune(a, b rune) bool        { return c.d.CompareRune(a, b) }
+func (c compareOnly) String() string                    { return c.d.String() }
+
+func TestDocument_Find(t *testing.T) {
+	text, err := NewDocument(".boa vida, c o c a i n a.", WithMinimumMatchScore(60))
+	if err != nil {
+		log.Fatal(err)
+	}
+	patterns := []string{"boa vida", "cocaina", "natura", "vida"}
+	var docs []Documenter
+	for _, p := range patterns {
+		d, err := NewDocument(p, WithMinimumMatchScore(60))
+		if err != nil {
+			log.Fatal(err)
+		}
+		docs = append(docs, d)
+	}
+	docs[3] = compareOnly{docs[3].(*Document)}
+	want := []Match{
+		{Index: 0, Sequence: []rune("boa vida"), Start: 1, End: 3, Score: 100},
+		{Index: 1, Sequence: []rune("c o c a i n a"), Start: 4, End: 11, Score: 100},
+		{Index: 3, Sequence: []rune("vida"), Start: -1, End: -1},
+	}
+	if got := text.Find(docs...); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDocument_MappedTokensChanged(t *testing.T) {
+	d, err := NewDocument("comida boa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Tokens = []string{"comida", "ruim"}
+	if got := string(d.Mapped().GetRunesByID(d.Mapped().Ids[1])); got != "ruim" {
+		t.Errorf("Mapped() token 1 = %q, want ruim", got)
+	}
+	text, err := NewDocument("que comida ruim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := d.Compare(text.Mapped()); !ok {
+		t.Error("Compare() = false after the Tokens changed, want true")
+	}
+}
@@ -0,0 +1,161 @@
+package gomtch
+
+import "fmt"
+
+// Flip is a way of writing a text backwards or upside down.
+type Flip int
+
+const (
+	// FlipNone is the text as it is.
+	FlipNone Flip = iota
+	// FlipReversedWords is each word written backwards, in the order of the phrase (ex: "yub aroproc").
+	FlipReversedWords
+	// FlipReversed is the whole text written backwards (ex: "aroproc yub").
+	FlipReversed
+	// FlipUpsideDown is the text rotated half a turn (ex: "ɐɹodɹoɔ").
+	FlipUpsideDown
+	// FlipMirrored is the text mirrored horizontally (ex: "ɒᴙoqᴙoɔ").
+	FlipMirrored
+)
+
+var flipNames = map[Flip]string{
+	FlipNone:          "none",
+	FlipReversedWords: "reversed words",
+	FlipReversed:      "reversed",
+	FlipUpsideDown:    "upside down",
+	FlipMirrored:      "mirrored",
+}
+
+func (f Flip) String() string {
+	if name, ok := flipNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("flip(%d)", int(f))
+}
+
+// upsideDownPairs are the runes that look like each other rotated half a turn.
+var upsideDownPairs = []string{
+	"aɐ", "bq", "cɔ", "dp", "eǝ", "fɟ", "gƃ", "hɥ", "iᴉ", "jɾ", "kʞ", "mɯ", "nu", "rɹ", "tʇ", "vʌ", "wʍ", "yʎ",
+	"A∀", "CƆ", "EƎ", "FℲ", "G⅁", "Jſ", "L˥", "MW", "PԀ", "TꞱ", "U∩", "VΛ", "Y⅄",
+	"69", "1Ɩ", "3Ɛ", "4ㄣ", "5ϛ", "7ㄥ", "!¡", "?¿", ".˙", "&⅋", "_‾",
+}
+
+// mirroredPairs are the runes that look like each other mirrored horizontally.
+var mirroredPairs = []string{
+	"bd", "pq", "aɒ", "cɔ", "eɘ", "rᴙ", "sƨ", "zƹ",
+	"CↃ", "EƎ", "Fꟻ", "NИ", "Pꟼ", "RЯ", "SꙄ", "?⸮",
+}
+
+var (
+	upsideDownRunes = flipTable(upsideDownPairs)
+	mirroredRunes   = flipTable(mirroredPairs)
+)
+
+// flipTable maps each rune of the pairs to the other one.
+func flipTable(pairs []string) map[rune]rune {
+	table := map[rune]rune{}
+	for _, pair := range pairs {
+		runes := []rune(pair)
+		table[runes[0]] = runes[1]
+		table[runes[1]] = runes[0]
+	}
+	return table
+}
+
+// WithFlippedText looks for the Document also in the text written backwards or upside down when it is not
+// found as it is. Without flips all of them are tried, in the order they are declared. The Match found
+// tells the Flip in its Flip field and its positions refer to the tokens as they were written.
+// The runes are flipped after the normalization so it should keep the flipped runes (ex: ɐ, ɹ).
+func WithFlippedText(flips ...Flip) Option {
+	return func(d *Document) {
+		if len(flips) == 0 {
+			flips = []Flip{FlipReversedWords, FlipReversed, FlipUpsideDown, FlipMirrored}
+		}
+		d.flips = flips
+	}
+}
+
+// flipTokens returns the tokens read as the flip undoes.
+func flipTokens(tokens Tokens, flip Flip) Tokens {
+	table := flipTableOf(flip)
+	flipped := Tokens{
+		Values: make(map[int][]rune, len(tokens.Values)),
+		Ids:    make([]int, len(tokens.Ids)),
+	}
+	for id, runes := range tokens.Values {
+		flipped.Values[id] = flipRunes(runes, table)
+	}
+	n := len(tokens.Ids)
+	for i, id := range tokens.Ids {
+		if flip == FlipReversedWords {
+			flipped.Ids[i] = id
+		} else {
+			flipped.Ids[n-1-i] = id
+		}
+	}
+	return flipped
+}
+
+// flipTableOf returns the runes replaced by the flip, if any.
+func flipTableOf(flip Flip) map[rune]rune {
+	switch flip {
+	case FlipUpsideDown:
+		return upsideDownRunes
+	case FlipMirrored:
+		return mirroredRunes
+	}
+	return nil
+}
+
+// flipRunes returns the runes backwards, replaced by the ones of the table.
+func flipRunes(runes []rune, table map[rune]rune) []rune {
+	r := make([]rune, len(runes))
+	for i, c := range runes {
+		if f, ok := table[c]; ok {
+			c = f
+		}
+		r[len(runes)-1-i] = c
+	}
+	return r
+}
+
+// flipSequence returns the sequence of tokens read as the flip undoes. Each flip undoes itself,
+// so it also returns a sequence found in the flipped tokens as it was written.
+func flipSequence(sequence []rune, flip Flip) []rune {
+	table := flipTableOf(flip)
+	if flip != FlipReversedWords {
+		return flipRunes(sequence, table)
+	}
+	r := make([]rune, 0, len(sequence))
+	start := 0
+	for i := 0; i <= len(sequence); i++ {
+		if i < len(sequence) && sequence[i] != whiteSpace {
+			continue
+		}
+		if start != 0 {
+			r = append(r, whiteSpace)
+		}
+		r = append(r, flipRunes(sequence[start:i], table)...)
+		start = i + 1
+	}
+	return r
+}
+
+// locateFlipped looks for the Document in the tokens flipped and returns the Match with the
+// positions and the sequence of the tokens as they were written.
+func (d Document) locateFlipped(tokens Tokens, describe bool) (Match, bool) {
+	n := len(tokens.Ids)
+	for _, flip := range d.flips {
+		m, ok := d.locate(flipTokens(tokens, flip), describe)
+		if !ok {
+			continue
+		}
+		m.Flip = flip
+		m.Sequence = flipSequence(m.Sequence, flip)
+		if flip != FlipReversedWords {
+			m.Start, m.End = n-m.End, n-m.Start
+		}
+		return m, true
+	}
+	return Match{}, false
+}
@@ -0,0 +1,63 @@
+package gomtch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithFlippedText(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		text    string
+		pattern string
+		want    Match
+		found   bool
+	}{
+		{"notFlipped", []Option{WithFlippedText()}, "buy corpora", "corpora",
+			Match{Sequence: []rune("corpora"), Start: 1, End: 2, Score: 100}, true},
+		{"reversedWords", []Option{WithFlippedText()}, "yub aroproc won", "buy corpora",
+			Match{Sequence: []rune("yub aroproc"), Start: 0, End: 2, Score: 100, Flip: FlipReversedWords}, true},
+		{"reversed", []Option{WithFlippedText()}, "won aroproc yub", "buy corpora",
+			Match{Sequence: []rune("aroproc yub"), Start: 1, End: 3, Score: 100, Flip: FlipReversed}, true},
+		{"reversedSplit", []Option{WithFlippedText()}, "buy an íac oc", "cocaína",
+			Match{Sequence: []rune("an íac oc"), Start: 1, End: 4, Score: 100, Flip: FlipReversed}, true},
+		{"reversedSplitPhrase", []Option{WithFlippedText()}, "an íac oc yub", "buy cocaína",
+			Match{Sequence: []rune("an íac oc yub"), Start: 0, End: 4, Score: 100, Flip: FlipReversed}, true},
+		{"upsideDown", []Option{WithFlippedText()}, "ʎnq ɐɹodɹoɔ", "corpora",
+			Match{Sequence: []rune("ɐɹodɹoɔ"), Start: 1, End: 2, Score: 100, Flip: FlipUpsideDown}, true},
+		{"upsideDownSplit", []Option{WithFlippedText(FlipUpsideDown)}, "ɐɹo dɹoɔ", "corpora",
+			Match{Sequence: []rune("ɐɹo dɹoɔ"), Start: 0, End: 2, Score: 100, Flip: FlipUpsideDown}, true},
+		{"upsideDownPhrase", []Option{WithFlippedText()}, "ʍou ɐɹodɹoɔ ʎnq", "buy corpora now",
+			Match{Sequence: []rune("ʍou ɐɹodɹoɔ ʎnq"), Start: 0, End: 3, Score: 100, Flip: FlipUpsideDown}, true},
+		{"mirrored", []Option{WithFlippedText()}, "ɒᴙoqᴙoɔ", "corpora",
+			Match{Sequence: []rune("ɒᴙoqᴙoɔ"), Start: 0, End: 1, Score: 100, Flip: FlipMirrored}, true},
+		{"onlyGivenFlips", []Option{WithFlippedText(FlipUpsideDown)}, "aroproc", "corpora", Match{}, false},
+		{"disabled", nil, "aroproc", "corpora", Match{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := locateIn(t, tt.text, tt.pattern, tt.opts...)
+			if ok != tt.found || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Locate() = %+v %v, want %+v %v", got, ok, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestWithFlippedText_Scan(t *testing.T) {
+	pattern, err := NewDocument("cocaína", WithFlippedText(), WithSetLower())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDocument("Compre ANÍACOC aqui", WithSetLower())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Scan(pattern); !reflect.DeepEqual(got, Matches{0: []rune("aníacoc")}) {
+		t.Errorf("Scan() = %v", got)
+	}
+	if got := d.Find(pattern); len(got) != 1 || got[0].Flip != FlipReversedWords || got[0].Flip.String() != "reversed words" {
+		t.Errorf("Find() = %+v", got)
+	}
+}
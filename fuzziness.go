@@ -0,0 +1,63 @@
+package gomtch
+
+// Fuzziness returns the number of runes of a token of the given length that can be masked.
+type Fuzziness func(length int) int
+
+var (
+	// FuzzinessExact masks no rune, the tokens must match exactly.
+	FuzzinessExact Fuzziness = func(int) int {
+		return 0
+	}
+	// FuzzinessAuto is the AUTO fuzziness of Elasticsearch: tokens of up to 2 runes must match exactly,
+	// tokens of 3 to 5 runes can have one rune masked and longer tokens two.
+	FuzzinessAuto = NewAutoFuzziness(3, 6)
+	// FuzzinessLenient lets a third of the runes of the tokens of 3 runes or more be masked
+	// (ex: one rune of 3 to 5, two of 6 to 8, three of 9 to 11).
+	FuzzinessLenient Fuzziness = func(length int) int {
+		if length < 3 {
+			return 0
+		}
+		return length / 3
+	}
+)
+
+// NewAutoFuzziness returns a Fuzziness like the AUTO:low,high of Elasticsearch: tokens shorter than
+// low runes must match exactly, tokens shorter than high runes can have one rune masked and the
+// others two.
+func NewAutoFuzziness(low, high int) Fuzziness {
+	return func(length int) int {
+		switch {
+		case length < low:
+			return 0
+		case length < high:
+			return 1
+		}
+		return 2
+	}
+}
+
+// NewFuzzinessScorer returns a Scorer accepting the tokens with up to f(length) runes masked.
+// The score is the percentage of the runes matched.
+func NewFuzzinessScorer(f Fuzziness) Scorer {
+	return ScorerFunc(func(c Comparison) (int, bool) {
+		if len(c.Pattern) == 0 {
+			return 0, false
+		}
+		return c.Matched() * 100 / len(c.Pattern), len(c.Wildcards) <= f(len(c.Pattern))
+	})
+}
+
+// WithFuzziness decides the number of runes of each token that can be masked from its length,
+// instead of a minimum match score that rounds down to all the runes of the short words and
+// allows too few masked runes in the long ones (ex: WithFuzziness(FuzzinessAuto)).
+func WithFuzziness(f Fuzziness) Option {
+	return func(d *Document) {
+		d.setMatchScore("WithFuzziness", nil, NewFuzzinessScorer(f))
+		d.minimumScore = func(length int) int {
+			if length == 0 {
+				return 100
+			}
+			return (length - f(length)) * 100 / length
+		}
+	}
+}
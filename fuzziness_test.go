@@ -0,0 +1,78 @@
+package gomtch
+
+import "testing"
+
+// TestWithFuzziness documents the masked variants each profile accepts.
+func TestWithFuzziness(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		text      string
+		exact     bool
+		auto      bool
+		lenient   bool
+		minimum90 bool
+	}{
+		{"ok", "ok", true, true, true, true},
+		{"oi", "o*", false, false, false, true},
+		{"cat", "c*t", false, true, true, true},
+		{"back", "b*ck", false, true, true, true},
+		{"back", "b**k", false, false, false, false},
+		{"vodka", "v*dka", false, true, true, true},
+		{"vodka", "v*d*a", false, false, false, false},
+		{"heroin", "h*roin", false, true, true, true},
+		{"heroin", "h*r*in", false, true, true, false},
+		{"heroin", "h*r**n", false, false, false, false},
+		{"cocaína", "c*c*ína", false, true, true, false},
+		{"cocaína", "c*c**na", false, false, false, false},
+		{"maconheiro", "m*c*nh*iro", false, false, true, false},
+		{"maconheiro", "m*c*nh**ro", false, false, false, false},
+		{"maconheiro", "m*conheiro", false, true, true, true},
+	}
+	profiles := []struct {
+		name string
+		opt  Option
+		want func(i int) bool
+	}{
+		{"exact", WithFuzziness(FuzzinessExact), func(i int) bool { return tests[i].exact }},
+		{"auto", WithFuzziness(FuzzinessAuto), func(i int) bool { return tests[i].auto }},
+		{"lenient", WithFuzziness(FuzzinessLenient), func(i int) bool { return tests[i].lenient }},
+		{"minimum90", WithMinimumMatchScore(90), func(i int) bool { return tests[i].minimum90 }},
+	}
+	for _, p := range profiles {
+		for i, tt := range tests {
+			t.Run(p.name+"/"+tt.text, func(t *testing.T) {
+				if _, got := locateIn(t, tt.text, tt.pattern, p.opt); got != p.want(i) {
+					t.Errorf("Locate() = %v, want %v", got, p.want(i))
+				}
+			})
+		}
+	}
+}
+
+func TestNewAutoFuzziness(t *testing.T) {
+	f := NewAutoFuzziness(4, 8)
+	for length, want := range map[int]int{1: 0, 3: 0, 4: 1, 7: 1, 8: 2, 20: 2} {
+		if got := f(length); got != want {
+			t.Errorf("NewAutoFuzziness(4, 8)(%d) = %d, want %d", length, got, want)
+		}
+	}
+}
+
+func TestWithFuzziness_LastOptionWins(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"minimumLast", []Option{WithFuzziness(FuzzinessAuto), WithMinimumMatchScore(100)}, false},
+		{"fuzzinessLast", []Option{WithMinimumMatchScore(100), WithFuzziness(FuzzinessAuto)}, true},
+		{"scorerLast", []Option{WithFuzziness(FuzzinessExact), WithScorer(NewMinimumScorer(80))}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := locateIn(t, "c*caína", "cocaína", tt.opts...); got != tt.want {
+				t.Errorf("Locate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package gomtch
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"strings"
+)
+
+// DefaultHTMLAttributes are the attributes holding text read by people or crawlers.
+var DefaultHTMLAttributes = []string{"alt", "title", "aria-label", "href"}
+
+// HTMLExtraction configures WithHTMLExtraction.
+type HTMLExtraction struct {
+	// Attributes are the names of the attributes whose values are extracted as well
+	// (ex: DefaultHTMLAttributes). No attribute is extracted if it is empty.
+	Attributes []string
+	// IncludeScripts extracts the contents of the script, style, noscript and template elements.
+	IncludeScripts bool
+}
+
+// HTMLPiece is a text extracted from an HTML node. Offset is the byte offset of Text in the text
+// returned by WithHTMLExtraction, before the options that run after it, while the Signals of the
+// hidden pieces have the offset of their HTML source (see WithOffsetTracking). Path locates the
+// node in the document (ex: /html[1]/body[1]/p[2]).
+// Attribute is the name of the attribute the Text was read from, if any, and Hidden tells
+// why the Text is not displayed, if it is not.
+type HTMLPiece struct {
+	Offset    int
+	Text      string
+	Path      string
+	Attribute string
+	Hidden    string
+}
+
+// WithHTMLExtraction extracts the text of an HTML document keeping its structure: block elements
+// (ex: p, div, li, td) and line breaks become new lines so their texts are not joined as WithHMTLParsing
+// does ("<p>buy</p><p>now</p>" = "buy\nnow"). The script and style contents are skipped unless
+// config.IncludeScripts is set and the values of config.Attributes are extracted as well.
+// A Signal is reported for each text hidden by inline CSS (ex: display:none, font-size:0 or text
+// in the same color as its background). Each text extracted is described by the HTMLPieces of the Document.
+func WithHTMLExtraction(config HTMLExtraction) Option {
+	return func(d *Document) {
+		d.addStep(StageMarkup, "WithHTMLExtraction", func(d *Document) {
+			root, err := html.Parse(strings.NewReader(d.Text))
+			if err != nil {
+				d.optError = err
+				return
+			}
+			e := htmlExtractor{config: config, b: d.newTextBuilder(len(d.Text)), source: d.newHTMLSource()}
+			e.walk(root, "", htmlStyle{})
+			e.source.setText(d, e.b, strings.TrimSuffix(e.b.String(), "\n"))
+			d.htmlPieces = append(d.htmlPieces, e.pieces...)
+			// the pieces are in the extracted text, the signals in the Original one if tracked
+			for _, p := range e.pieces {
+				if p.Hidden != "" {
+					d.addSignal(Signal{
+						Kind:   SignalHiddenText,
+						Offset: d.originalOffset(p.Offset),
+						Text:   p.Text,
+						Detail: fmt.Sprintf("%s at %s", p.Hidden, p.Path),
+					})
+				}
+			}
+		})
+	}
+}
+
+// HTMLPieces returns the texts extracted by WithHTMLExtraction with the HTML nodes they came from.
+func (d Document) HTMLPieces() []HTMLPiece {
+	return d.htmlPieces
+}
+
+var (
+	htmlBlocks = []string{"address", "article", "aside", "blockquote", "body", "caption", "dd", "details",
+		"dialog", "div", "dl", "dt", "fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3",
+		"h4", "h5", "h6", "head", "header", "hr", "li", "main", "nav", "ol", "option", "p", "pre", "section",
+		"summary", "table", "tbody", "td", "tfoot", "th", "thead", "title", "tr", "ul"}
+	htmlScripts = []string{"script", "style", "noscript", "template"}
+)
+
+// htmlStyle is the inline CSS inherited by a node.
+type htmlStyle struct {
+	hidden     string
+	color      string
+	background string
+}
+
+type htmlExtractor struct {
+	config HTMLExtraction
+	b      *textBuilder
+	source *htmlSource
+	pieces []HTMLPiece
+}
+
+func (e *htmlExtractor) walk(n *html.Node, path string, style htmlStyle) {
+	switch n.Type {
+	case html.TextNode:
+		e.write(n.Data, path, "", style.hidden)
+		return
+	case html.ElementNode:
+		if !e.config.IncludeScripts && stringIn(n.Data, htmlScripts) {
+			return
+		}
+		path = fmt.Sprintf("%s/%s[%d]", path, n.Data, htmlIndex(n))
+		style = inlineStyle(n, style)
+		block := stringIn(n.Data, htmlBlocks) || n.Data == "br"
+		if block {
+			e.boundary()
+		}
+		for _, attr := range n.Attr {
+			if stringIn(attr.Key, e.config.Attributes) && strings.TrimSpace(attr.Val) != "" {
+				e.boundary()
+				e.write(attr.Val, path, attr.Key, style.hidden)
+				e.boundary()
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			e.walk(c, path, style)
+		}
+		if block {
+			e.boundary()
+		}
+		return
+	case html.DocumentNode:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			e.walk(c, path, style)
+		}
+	}
+}
+
+// write adds the text to the extracted text. Blank texts are kept as they separate the inline
+// elements but are not recorded as pieces.
+func (e *htmlExtractor) write(text, path, attribute, hidden string) {
+	if strings.TrimSpace(text) == "" {
+		if e.b.Len() > 0 && text != "" {
+			e.source.skip(text)
+			e.b.insert(" ")
+		}
+		return
+	}
+	e.pieces = append(e.pieces, HTMLPiece{
+		Offset:    e.b.Len(),
+		Text:      text,
+		Path:      path,
+		Attribute: attribute,
+		Hidden:    hidden,
+	})
+	if attribute != "" {
+		e.source.writeAttribute(e.b, text)
+		return
+	}
+	e.source.write(e.b, text)
+}
+
+// boundary ends the current line of the extracted text.
+func (e *htmlExtractor) boundary() {
+	if s := e.b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		e.b.insert("\n")
+	}
+}
+
+// htmlSource maps the texts of the nodes of a parsed HTML document to where the tokenizer found
+// them in the source, in the order they are written, so the text extracted from the nodes keeps
+// the map of its step. Its texts are only read if the offsets are tracked.
+type htmlSource struct {
+	source string
+	texts  []htmlText
+	tags   []htmlText
+	// next and nextTag are the first text and start tag not mapped yet
+	next, nextTag int
+	// failed is set when a text is not found in the source, the text extracted is then mapped as a whole
+	failed bool
+}
+
+// htmlText is a text token, or the raw start tag, of the source at the bytes [start, end).
+type htmlText struct {
+	text       string
+	start, end int
+}
+
+func (d Document) newHTMLSource() *htmlSource {
+	s := &htmlSource{source: d.Text}
+	if d.offsets == nil {
+		return s
+	}
+	z := html.NewTokenizer(strings.NewReader(d.Text))
+	offset := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return s
+		}
+		size := len(z.Raw())
+		switch tt {
+		case html.TextToken:
+			s.texts = append(s.texts, htmlText{text: string(z.Text()), start: offset, end: offset + size})
+		case html.StartTagToken, html.SelfClosingTagToken:
+			s.tags = append(s.tags, htmlText{text: d.Text[offset : offset+size], start: offset, end: offset + size})
+		}
+		offset += size
+	}
+}
+
+// find returns the index after the texts of the source from next on that, one after the other, are
+// the text of a node, and the index of the first of them.
+func (s *htmlSource) find(text string) (int, int, bool) {
+	for first := s.next; first < len(s.texts); first++ {
+		rest, k := text, first
+		for rest != "" && k < len(s.texts) && s.texts[k].text != "" && strings.HasPrefix(rest, s.texts[k].text) {
+			rest = rest[len(s.texts[k].text):]
+			k++
+		}
+		if rest == "" {
+			return first, k, true
+		}
+	}
+	return 0, 0, false
+}
+
+// write appends the text of a node to b, in place of the texts of the source it was parsed from.
+func (s *htmlSource) write(b *textBuilder, text string) {
+	if !b.track || s.failed {
+		b.WriteString(text)
+		return
+	}
+	first, end, ok := s.find(text)
+	if !ok {
+		s.failed = true
+		b.WriteString(text)
+		return
+	}
+	for _, t := range s.texts[first:end] {
+		raw := s.source[t.start:t.end]
+		if raw == t.text {
+			b.write(t.text, t.start, t.end)
+			continue
+		}
+		// the entities are decoded each one in place of its own bytes
+		n := b.Len()
+		writeEntities(b, raw, t.start)
+		if b.String()[n:] != t.text {
+			s.failed = true
+		}
+	}
+	s.next = end
+}
+
+// skip maps a blank text of a node, not written, so the next texts are looked for after it.
+func (s *htmlSource) skip(text string) {
+	if s.failed || len(s.texts) == 0 {
+		return
+	}
+	if _, end, ok := s.find(text); ok {
+		s.next = end
+	}
+}
+
+// writeAttribute appends the value of an attribute to b, in place of the value in the start tag it was
+// read from, or of the whole tag if the value is not written in it as it is.
+func (s *htmlSource) writeAttribute(b *textBuilder, value string) {
+	if !b.track || s.failed {
+		b.WriteString(value)
+		return
+	}
+	for k := s.nextTag; k < len(s.tags); k++ {
+		t := s.tags[k]
+		if s.next < len(s.texts) && t.start > s.texts[s.next].start {
+			break
+		}
+		if at := strings.Index(t.text, value); at >= 0 {
+			b.write(value, t.start+at, t.start+at+len(value))
+			s.nextTag = k
+			return
+		}
+	}
+	s.failed = true
+	b.WriteString(value)
+}
+
+// setText replaces the text being normalized by the text extracted, built by b, which may have its
+// end trimmed.
+func (s *htmlSource) setText(d *Document, b *textBuilder, text string) {
+	if s.failed {
+		d.setText(text, wholeMap(d.Text, text))
+		return
+	}
+	d.setText(text, b.m)
+}
+
+// htmlIndex returns the position of the element among its siblings of the same name, starting at 1.
+func htmlIndex(n *html.Node) int {
+	index := 1
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode && s.Data == n.Data {
+			index++
+		}
+	}
+	return index
+}
+
+// inlineStyle returns the style of the element given the one inherited from its parent.
+func inlineStyle(n *html.Node, style htmlStyle) htmlStyle {
+	var declarations string
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "hidden":
+			if style.hidden == "" {
+				style.hidden = "hidden attribute"
+			}
+		case "style":
+			declarations = attr.Val
+		}
+	}
+	for _, declaration := range strings.Split(declarations, ";") {
+		i := strings.Index(declaration, ":")
+		if i < 0 {
+			continue
+		}
+		property := strings.ToLower(strings.TrimSpace(declaration[:i]))
+		value := strings.ToLower(strings.TrimSpace(strings.TrimSuffix(
+			strings.TrimSpace(declaration[i+1:]), "!important")))
+		var hidden string
+		switch property {
+		case "display":
+			if value == "none" {
+				hidden = "display:none"
+			}
+		case "visibility":
+			if value == "hidden" || value == "collapse" {
+				hidden = "visibility:" + value
+			}
+		case "opacity":
+			if value != "" && strings.Trim(value, "0.%") == "" {
+				hidden = "opacity:0"
+			}
+		case "font-size":
+			if isZeroLength(value) {
+				hidden = "font-size:0"
+			}
+		case "color":
+			style.color = cssColor(value)
+		case "background-color", "background":
+			style.background = cssColor(value)
+		}
+		if hidden != "" && style.hidden == "" {
+			style.hidden = hidden
+		}
+	}
+	if style.hidden == "" && style.color != "" && style.color == style.background {
+		style.hidden = "same color as the background"
+	}
+	return style
+}
+
+// isZeroLength reports whether the CSS length is zero in any unit (ex: 0, 0px, 0.0em).
+func isZeroLength(value string) bool {
+	number := strings.TrimRight(value, "abcdefghijklmnopqrstuvwxyz%")
+	return number != "" && strings.Trim(number, "0.") == ""
+}
+
+var cssColorNames = map[string]string{
+	"white": "#ffffff", "black": "#000000", "red": "#ff0000", "lime": "#00ff00", "blue": "#0000ff",
+	"yellow": "#ffff00", "gray": "#808080", "grey": "#808080", "silver": "#c0c0c0",
+}
+
+// cssColor returns the color in the #rrggbb form when it is a known name or a hexadecimal color.
+func cssColor(value string) string {
+	value = strings.Join(strings.Fields(value), "")
+	if hex, ok := cssColorNames[value]; ok {
+		return hex
+	}
+	if len(value) == 4 && value[0] == '#' {
+		return string([]byte{'#', value[1], value[1], value[2], value[2], value[3], value[3]})
+	}
+	return value
+}
@@ -0,0 +1,102 @@
+package gomtch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithHTMLExtraction(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		config HTMLExtraction
+		want   string
+	}{
+		{"blocks", "<p>buy</p><p>now</p>", HTMLExtraction{}, "buy\nnow"},
+		{"inline", "<p><b>coca</b>ína <i>pura</i></p>", HTMLExtraction{}, "cocaína pura"},
+		{"lineBreak", "buy<br>now", HTMLExtraction{}, "buy\nnow"},
+		{"table", "<table><tr><td>coca</td><td>ína</td></tr></table>", HTMLExtraction{}, "coca\nína"},
+		{"entities", "<p>coca&iacute;na &amp; crack</p>", HTMLExtraction{}, "cocaína & crack"},
+		{"scripts", "<p>buy</p><script>var x = 'now'</script><style>p {}</style>", HTMLExtraction{},
+			"buy"},
+		{"includeScripts", "<p>buy</p><script>now</script>", HTMLExtraction{IncludeScripts: true},
+			"buy\nnow"},
+		{"comments", "<p>buy<!-- now --></p>", HTMLExtraction{}, "buy"},
+		{"attributesIgnored", `<p>buy <img alt="cocaína"></p>`, HTMLExtraction{}, "buy "},
+		{"attributes", `<p>buy <img alt="cocaína"> <a href="/now" title="here">now</a></p>`,
+			HTMLExtraction{Attributes: DefaultHTMLAttributes}, "buy \ncocaína\n \n/now\nhere\nnow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.html, WithHTMLExtraction(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Text != tt.want {
+				t.Errorf("Text = %q, want %q", d.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithHTMLExtraction_Pieces(t *testing.T) {
+	d, err := NewDocument(`<div><p>buy</p><p>now <img alt="coca"></p></div>`,
+		WithHTMLExtraction(HTMLExtraction{Attributes: []string{"alt"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HTMLPiece{
+		{Offset: 0, Text: "buy", Path: "/html[1]/body[1]/div[1]/p[1]"},
+		{Offset: 4, Text: "now ", Path: "/html[1]/body[1]/div[1]/p[2]"},
+		{Offset: 9, Text: "coca", Path: "/html[1]/body[1]/div[1]/p[2]/img[1]", Attribute: "alt"},
+	}
+	if got := d.HTMLPieces(); !reflect.DeepEqual(got, want) {
+		t.Errorf("HTMLPieces() = %v, want %v", got, want)
+	}
+	if got := d.Tokens; !reflect.DeepEqual(got, []string{"buy", "now", "coca"}) {
+		t.Errorf("Tokens = %q", got)
+	}
+}
+
+func TestWithHTMLExtraction_Hidden(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []Signal
+	}{
+		{"visible", `<p style="color: red">buy</p>`, nil},
+		{"emptyOpacity", `<p style="opacity:">buy</p>`, nil},
+		{"displayNone", `<p>buy <span style="display: none">cocaína</span></p>`, []Signal{{
+			Kind: SignalHiddenText, Offset: 35, Text: "cocaína",
+			Detail: "display:none at /html[1]/body[1]/p[1]/span[1]"}}},
+		{"inherited", `<div style="visibility:hidden"><p><b>cocaína</b></p></div>`, []Signal{{
+			Kind: SignalHiddenText, Offset: 37, Text: "cocaína",
+			Detail: "visibility:hidden at /html[1]/body[1]/div[1]/p[1]/b[1]"}}},
+		{"fontSize", `<p style="font-size:0px !important">cocaína</p>`, []Signal{{
+			Kind: SignalHiddenText, Offset: 36, Text: "cocaína", Detail: "font-size:0 at /html[1]/body[1]/p[1]"}}},
+		{"opacity", `<p style="opacity: 0.0">cocaína</p>`, []Signal{{
+			Kind: SignalHiddenText, Offset: 24, Text: "cocaína", Detail: "opacity:0 at /html[1]/body[1]/p[1]"}}},
+		{"sameColor", `<div style="background-color: #FFF"><p style="color: white">cocaína</p></div>`,
+			[]Signal{{Kind: SignalHiddenText, Offset: 60, Text: "cocaína",
+				Detail: "same color as the background at /html[1]/body[1]/div[1]/p[1]"}}},
+		{"hiddenAttribute", `<p hidden>cocaína</p>`, []Signal{{
+			Kind: SignalHiddenText, Offset: 10, Text: "cocaína", Detail: "hidden attribute at /html[1]/body[1]/p[1]"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.html, WithHTMLExtraction(HTMLExtraction{}), WithOffsetTracking())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Signals(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Signals() = %v, want %v", got, tt.want)
+			}
+			for _, s := range d.Signals() {
+				if !strings.HasPrefix(tt.html[s.Offset:], s.Text) {
+					t.Errorf("Original()[%d:] = %q, want %q", s.Offset, tt.html[s.Offset:], s.Text)
+				}
+			}
+		})
+	}
+}
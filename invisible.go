@@ -0,0 +1,168 @@
+package gomtch
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InvisibleClass is a set of classes of characters that are not displayed (or barely are)
+// but split the words for the matching.
+type InvisibleClass uint
+
+const (
+	// InvisibleZeroWidth are the zero width spaces, joiners and non-joiners, word joiners,
+	// byte order marks and invisible operators.
+	InvisibleZeroWidth InvisibleClass = 1 << iota
+	// InvisibleSoftHyphen are the soft hyphens, only displayed when a word is broken at the end of a line.
+	InvisibleSoftHyphen
+	// InvisibleVariationSelector are the variation selectors changing the glyph of the previous character.
+	InvisibleVariationSelector
+	// InvisibleBidi are the bidirectional marks, embeddings, overrides and isolates.
+	InvisibleBidi
+	// InvisibleTag are the tag characters (U+E0000 to U+E007F).
+	InvisibleTag
+	// InvisibleFiller are the blank letters displayed as empty spaces (ex: U+3164 Hangul filler).
+	InvisibleFiller
+	// InvisibleMarkStack are the combining marks stacked over a character beyond maxStackedMarks
+	// (ex: "Zalgo" text). The first marks are kept.
+	InvisibleMarkStack
+
+	// InvisibleAll are all the classes above.
+	InvisibleAll = InvisibleZeroWidth | InvisibleSoftHyphen | InvisibleVariationSelector | InvisibleBidi |
+		InvisibleTag | InvisibleFiller | InvisibleMarkStack
+)
+
+var invisibleNames = map[InvisibleClass]string{
+	InvisibleZeroWidth:         "zero width",
+	InvisibleSoftHyphen:        "soft hyphen",
+	InvisibleVariationSelector: "variation selector",
+	InvisibleBidi:              "bidi control",
+	InvisibleTag:               "tag",
+	InvisibleFiller:            "filler",
+	InvisibleMarkStack:         "stacked mark",
+}
+
+// invisibleClasses are the single classes in the order they are reported.
+var invisibleClasses = []InvisibleClass{InvisibleZeroWidth, InvisibleSoftHyphen, InvisibleVariationSelector,
+	InvisibleBidi, InvisibleTag, InvisibleFiller, InvisibleMarkStack}
+
+func (c InvisibleClass) String() string {
+	var names []string
+	for _, class := range invisibleClasses {
+		if c&class != 0 {
+			names = append(names, invisibleNames[class])
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("invisible(%d)", uint(c))
+	}
+	return strings.Join(names, "|")
+}
+
+// maxStackedMarks is the number of combining marks over a single character kept by InvisibleMarkStack.
+// Two marks are enough for any language (ex: Vietnamese ệ).
+const maxStackedMarks = 2
+
+// invisibleClassOf returns the class of r or zero if r is not invisible. The stacked marks
+// depend on the previous runes so they are not classified here.
+func invisibleClassOf(r rune) InvisibleClass {
+	switch {
+	case r == '\u200b' || r == '\u200c' || r == '\u200d' || r == '\u2060' || r == '\ufeff' ||
+		r == '\u180e' || r == '\u034f' || (r >= '\u2061' && r <= '\u2064'):
+		return InvisibleZeroWidth
+	case r == '\u00ad':
+		return InvisibleSoftHyphen
+	case (r >= '\ufe00' && r <= '\ufe0f') || (r >= '\U000e0100' && r <= '\U000e01ef') ||
+		(r >= '\u180b' && r <= '\u180d') || r == '\u180f':
+		return InvisibleVariationSelector
+	case r == '\u200e' || r == '\u200f' || r == '\u061c' || (r >= '\u202a' && r <= '\u202e') ||
+		(r >= '\u2066' && r <= '\u2069'):
+		return InvisibleBidi
+	case r >= '\U000e0000' && r <= '\U000e007f':
+		return InvisibleTag
+	case r == '\u3164' || r == '\u115f' || r == '\u1160' || r == '\uffa0' || r == '\u2800':
+		return InvisibleFiller
+	}
+	return 0
+}
+
+// Removal is a run of characters removed from the text. Offset is the byte offset of Text
+// in the Original text with WithOffsetTracking, in the text given to WithInvisibleRemoval otherwise.
+type Removal struct {
+	Offset int
+	Text   string
+	Class  InvisibleClass
+}
+
+// WithInvisibleRemoval removes the characters of the given classes from the text
+// (ex: "co\u200bca\u00adína" = cocaína). Each run of characters removed is recorded in the
+// Removals of the Document and a Signal is reported for each class found, with the first characters removed
+// and how many were removed.
+func WithInvisibleRemoval(classes InvisibleClass) Option {
+	return func(d *Document) {
+		d.addStep(StageInvisibleRemoval, "WithInvisibleRemoval", func(d *Document) {
+			d.removeInvisible(classes)
+		})
+	}
+}
+
+// removeInvisible removes the characters of the classes from the text being normalized, recording
+// the removals and the signals in the Document.
+func (d *Document) removeInvisible(classes InvisibleClass) {
+	text := d.Text
+	b := d.newTextBuilder(len(text))
+	counts := map[InvisibleClass]int{}
+	firsts := map[InvisibleClass]int{}
+	var removed []Removal
+	var marks int
+	for i, r := range text {
+		class := invisibleClassOf(r)
+		if class == 0 && unicode.In(r, unicode.Mn, unicode.Me) {
+			marks++
+			if marks > maxStackedMarks {
+				class = InvisibleMarkStack
+			}
+		} else if class == 0 {
+			marks = 0
+		}
+		if class&classes == 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			b.writeRune(r, i, i+size)
+			continue
+		}
+		if _, ok := firsts[class]; !ok {
+			firsts[class] = len(removed)
+		}
+		counts[class]++
+		if last := len(removed) - 1; last >= 0 && removed[last].Class == class &&
+			removed[last].Offset+len(removed[last].Text) == i {
+			removed[last].Text += string(r)
+			continue
+		}
+		removed = append(removed, Removal{Offset: i, Text: string(r), Class: class})
+	}
+	// the removals are found in the text given to the step, the ones recorded are in the Original text if tracked
+	for i := range removed {
+		removed[i].Offset = d.originalOffset(removed[i].Offset)
+	}
+	b.setText(d)
+	d.removals = append(d.removals, removed...)
+	for _, class := range invisibleClasses {
+		if n := counts[class]; n > 0 {
+			first := removed[firsts[class]]
+			d.addSignal(Signal{
+				Kind:   SignalInvisible,
+				Offset: first.Offset,
+				Text:   first.Text,
+				Detail: fmt.Sprintf("%d %s characters removed", n, class),
+			})
+		}
+	}
+}
+
+// Removals returns the characters removed from the text of the Document by WithInvisibleRemoval.
+func (d Document) Removals() []Removal {
+	return d.removals
+}
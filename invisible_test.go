@@ -0,0 +1,107 @@
+package gomtch
+
+import (
+	"log"
+	"reflect"
+	"testing"
+)
+
+func TestWithInvisibleRemoval(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		classes      InvisibleClass
+		want         string
+		wantRemovals []Removal
+	}{
+		{"none", "cocaína", InvisibleAll, "cocaína", nil},
+		{"zeroWidth", "co\u200bca\u200b\u200bína", InvisibleAll, "cocaína",
+			[]Removal{{2, "\u200b", InvisibleZeroWidth}, {7, "\u200b\u200b", InvisibleZeroWidth}}},
+		{"softHyphen", "co\u00adca\u00adína", InvisibleAll, "cocaína",
+			[]Removal{{2, "\u00ad", InvisibleSoftHyphen}, {6, "\u00ad", InvisibleSoftHyphen}}},
+		{"wordJoiner", "coca\u2060ína", InvisibleAll, "cocaína", []Removal{{4, "\u2060", InvisibleZeroWidth}}},
+		{"variationSelector", "coca\ufe0fína", InvisibleAll, "cocaína",
+			[]Removal{{4, "\ufe0f", InvisibleVariationSelector}}},
+		{"bidi", "\u202eanaícoc\u202c", InvisibleAll, "anaícoc",
+			[]Removal{{0, "\u202e", InvisibleBidi}, {11, "\u202c", InvisibleBidi}}},
+		{"tag", "coca\U000e0069na", InvisibleAll, "cocana", []Removal{{4, "\U000e0069", InvisibleTag}}},
+		{"filler", "coca\u3164ína", InvisibleAll, "cocaína", []Removal{{4, "\u3164", InvisibleFiller}}},
+		{"zalgo", "c\u0301\u0302\u0303\u0304oca", InvisibleAll, "c\u0301\u0302oca",
+			[]Removal{{5, "\u0303\u0304", InvisibleMarkStack}}},
+		{"zalgoSplitByZeroWidth", "c\u0301\u0302\u200b\u0303oca", InvisibleAll, "c\u0301\u0302oca",
+			[]Removal{{5, "\u200b", InvisibleZeroWidth}, {8, "\u0303", InvisibleMarkStack}}},
+		{"vietnamese", "tiếng Việt", InvisibleAll, "tiếng Việt", nil},
+		{"onlyZeroWidth", "co\u200bca\u00adína", InvisibleZeroWidth, "coca\u00adína",
+			[]Removal{{2, "\u200b", InvisibleZeroWidth}}},
+		{"selectedClasses", "co\u200bca\u00ad\u202eína", InvisibleSoftHyphen | InvisibleBidi, "co\u200bcaína",
+			[]Removal{{7, "\u00ad", InvisibleSoftHyphen}, {9, "\u202e", InvisibleBidi}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, WithInvisibleRemoval(tt.classes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Text != tt.want {
+				t.Errorf("Text = %q, want %q", d.Text, tt.want)
+			}
+			if got := d.Removals(); !reflect.DeepEqual(got, tt.wantRemovals) {
+				t.Errorf("Removals() = %q, want %q", got, tt.wantRemovals)
+			}
+		})
+	}
+}
+
+func TestWithInvisibleRemoval_Signals(t *testing.T) {
+	d, err := NewDocument("compre co\u200bca\u200bí\u00adna aqui",
+		WithTransform(NewASCII()), WithInvisibleRemoval(InvisibleAll))
+	if err != nil {
+		log.Fatal(err)
+	}
+	want := []Signal{
+		{Kind: SignalInvisible, Offset: 9, Text: "\u200b", Detail: "2 zero width characters removed"},
+		{Kind: SignalInvisible, Offset: 19, Text: "\u00ad", Detail: "1 soft hyphen characters removed"},
+	}
+	if got := d.Signals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Signals() = %v, want %v", got, want)
+	}
+	pattern, err := NewDocument("cocaina")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if got := d.Scan(pattern); !reflect.DeepEqual(got, Matches{0: []rune("cocaina")}) {
+		t.Errorf("Scan() = %v", got)
+	}
+}
+
+func TestWithInvisibleRemoval_OriginalOffsets(t *testing.T) {
+	text := "<p>compre <b>co\u200bca</b>\u00adína</p>"
+	d, err := NewDocument(text, WithHMTLParsing(), WithInvisibleRemoval(InvisibleAll), WithOffsetTracking())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range d.Removals() {
+		if got := text[r.Offset : r.Offset+len(r.Text)]; got != r.Text {
+			t.Errorf("Original()[%d:] = %q, want %q", r.Offset, got, r.Text)
+		}
+	}
+	if len(d.Removals()) != 2 {
+		t.Errorf("Removals() = %q", d.Removals())
+	}
+}
+
+func TestInvisibleClass_String(t *testing.T) {
+	tests := []struct {
+		class InvisibleClass
+		want  string
+	}{
+		{InvisibleZeroWidth, "zero width"},
+		{InvisibleSoftHyphen | InvisibleBidi, "soft hyphen|bidi control"},
+		{0, "invisible(0)"},
+	}
+	for _, tt := range tests {
+		if got := tt.class.String(); got != tt.want {
+			t.Errorf("String() = %v, want %v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gomtch
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// latinFolding maps the Latin letters with no canonical decomposition (so the accent removal of ASCII
+// keeps them) to their ASCII form, following the Latin-ASCII transliteration of CLDR. Ligatures and
+// digraphs expand to many letters (ex: æ = ae, ß = ss). Letters that are not read as a basic Latin letter
+// (ex: ezh, yogh, wynn, clicks, glottal stops, tone letters) and the turned or reversed ones are not mapped.
+var latinFolding = map[rune]string{
+	// Latin-1 Supplement
+	'Æ': "AE", 'Ð': "D", 'Ø': "O", 'Þ': "TH", 'ß': "ss", 'æ': "ae", 'ð': "d", 'ø': "o", 'þ': "th",
+	// Latin Extended-A
+	'Đ': "D", 'đ': "d", 'Ħ': "H", 'ħ': "h", 'ı': "i", 'Ĳ': "IJ", 'ĳ': "ij", 'ĸ': "q", 'Ŀ': "L", 'ŀ': "l",
+	'Ł': "L", 'ł': "l", 'ŉ': "n", 'Ŋ': "N", 'ŋ': "n", 'Œ': "OE", 'œ': "oe", 'Ŧ': "T", 'ŧ': "t", 'ſ': "s",
+	// Latin Extended-B
+	'ƀ': "b", 'Ɓ': "B", 'Ƃ': "B", 'ƃ': "b", 'Ɔ': "O", 'Ƈ': "C", 'ƈ': "c", 'Ɖ': "D", 'Ɗ': "D", 'Ƌ': "D",
+	'ƌ': "d", 'Ɛ': "E", 'Ƒ': "F", 'ƒ': "f", 'Ɠ': "G", 'Ɣ': "G", 'ƕ': "hv", 'Ɩ': "I", 'Ɨ': "I", 'Ƙ': "K",
+	'ƙ': "k", 'ƚ': "l", 'Ɲ': "N", 'ƞ': "n", 'Ɵ': "O", 'Ƣ': "OI", 'ƣ': "oi", 'Ƥ': "P", 'ƥ': "p", 'ƫ': "t",
+	'Ƭ': "T", 'ƭ': "t", 'Ʈ': "T", 'Ʊ': "U", 'Ʋ': "V", 'Ƴ': "Y", 'ƴ': "y", 'Ƶ': "Z", 'ƶ': "z", 'Ǆ': "DZ",
+	'ǅ': "Dz", 'ǆ': "dz", 'Ǉ': "LJ", 'ǈ': "Lj", 'ǉ': "lj", 'Ǌ': "NJ", 'ǋ': "Nj", 'ǌ': "nj", 'Ǥ': "G", 'ǥ': "g",
+	'Ǳ': "DZ", 'ǲ': "Dz", 'ǳ': "dz", 'Ƞ': "N", 'ȡ': "d", 'Ȣ': "OU", 'ȣ': "ou", 'Ȥ': "Z", 'ȥ': "z", 'ȴ': "l",
+	'ȵ': "n", 'ȶ': "t", 'ȷ': "j", 'ȸ': "db", 'ȹ': "qp", 'Ⱥ': "A", 'Ȼ': "C", 'ȼ': "c", 'Ƚ': "L", 'Ⱦ': "T",
+	'ȿ': "s", 'ɀ': "z", 'Ƀ': "B", 'Ʉ': "U", 'Ɇ': "E", 'ɇ': "e", 'Ɉ': "J", 'ɉ': "j", 'Ɋ': "Q", 'ɋ': "q",
+	'Ɍ': "R", 'ɍ': "r", 'Ɏ': "Y", 'ɏ': "y",
+	// IPA and Phonetic Extensions (lowercase forms of the capitals above)
+	'ɑ': "a", 'ɓ': "b", 'ɔ': "o", 'ɖ': "d", 'ɗ': "d", 'ɛ': "e", 'ɠ': "g", 'ɡ': "g", 'ɣ': "g", 'ɦ': "h",
+	'ɨ': "i", 'ɩ': "i", 'ɪ': "i", 'ɫ': "l", 'ɬ': "l", 'ɱ': "m", 'ɲ': "n", 'ɵ': "o", 'ɽ': "r", 'ʂ': "s",
+	'ʈ': "t", 'ʉ': "u", 'ʊ': "u", 'ʋ': "v", 'ʝ': "j", 'ᵹ': "g", 'ᵽ': "p", 'ᶎ': "z",
+	// Latin Extended Additional
+	'ẚ': "a", 'ẜ': "s", 'ẝ': "s", 'ẞ': "SS", 'ẟ': "d", 'Ỻ': "LL", 'ỻ': "ll", 'Ỽ': "V", 'ỽ': "v", 'Ỿ': "Y",
+	'ỿ': "y",
+	// Latin Extended-C
+	'Ⱡ': "L", 'ⱡ': "l", 'Ɫ': "L", 'Ᵽ': "P", 'Ɽ': "R", 'ⱥ': "a", 'ⱦ': "t", 'Ⱨ': "H", 'ⱨ': "h", 'Ⱪ': "K",
+	'ⱪ': "k", 'Ⱬ': "Z", 'ⱬ': "z", 'Ɑ': "A", 'Ɱ': "M", 'ⱱ': "v", 'Ⱳ': "W", 'ⱳ': "w", 'ⱴ': "v", 'ⱸ': "e",
+	'ⱺ': "o", 'ⱽ': "V", 'Ȿ': "S", 'Ɀ': "Z",
+	// Latin Extended-D
+	'Ꜩ': "TZ", 'ꜩ': "tz", 'ꜰ': "f", 'ꜱ': "s", 'Ꜳ': "AA", 'ꜳ': "aa", 'Ꜵ': "AO", 'ꜵ': "ao", 'Ꜷ': "AU", 'ꜷ': "au",
+	'Ꜹ': "AV", 'ꜹ': "av", 'Ꜻ': "AV", 'ꜻ': "av", 'Ꜽ': "AY", 'ꜽ': "ay", 'Ꝁ': "K", 'ꝁ': "k", 'Ꝃ': "K", 'ꝃ': "k",
+	'Ꝅ': "K", 'ꝅ': "k", 'Ꝇ': "L", 'ꝇ': "l", 'Ꝉ': "L", 'ꝉ': "l", 'Ꝋ': "O", 'ꝋ': "o", 'Ꝍ': "O", 'ꝍ': "o",
+	'Ꝏ': "OO", 'ꝏ': "oo", 'Ꝑ': "P", 'ꝑ': "p", 'Ꝓ': "P", 'ꝓ': "p", 'Ꝗ': "Q", 'ꝗ': "q", 'Ꝙ': "Q", 'ꝙ': "q",
+	'Ꝛ': "R", 'ꝛ': "r", 'Ꝟ': "V", 'ꝟ': "v", 'Ꝡ': "VY", 'ꝡ': "vy", 'Ꝥ': "TH", 'ꝥ': "th", 'Ꝧ': "TH", 'ꝧ': "th",
+	'ꝱ': "d", 'ꝲ': "l", 'ꝳ': "m", 'ꝴ': "n", 'ꝷ': "t", 'ꝸ': "um", 'Ꝺ': "D", 'ꝺ': "d", 'Ꝼ': "F", 'ꝼ': "f",
+	'Ᵹ': "G", 'Ꞃ': "R", 'ꞃ': "r", 'Ꞅ': "S", 'ꞅ': "s", 'Ꞇ': "T", 'ꞇ': "t", 'ꞎ': "l", 'Ꞑ': "N", 'ꞑ': "n",
+	'Ꞓ': "C", 'ꞓ': "c", 'ꞔ': "c", 'ꞕ': "h", 'Ꞗ': "B", 'ꞗ': "b", 'Ꞙ': "F", 'ꞙ': "f", 'Ꞛ': "AE", 'ꞛ': "ae",
+	'Ꞝ': "OE", 'ꞝ': "oe", 'Ꞟ': "UE", 'ꞟ': "ue", 'Ꞡ': "G", 'ꞡ': "g", 'Ꞣ': "K", 'ꞣ': "k", 'Ꞥ': "N", 'ꞥ': "n",
+	'Ꞧ': "R", 'ꞧ': "r", 'Ꞩ': "S", 'ꞩ': "s", 'Ɦ': "H", 'Ɡ': "G", 'Ɬ': "L", 'Ɪ': "I", 'ꞯ': "q", 'Ʝ': "J",
+	'Ꞹ': "U", 'ꞹ': "u", 'Ꟁ': "O", 'ꟁ': "o", 'Ꟃ': "W", 'ꟃ': "w", 'Ꞔ': "C", 'Ʂ': "S", 'Ᶎ': "Z", 'Ꟈ': "D",
+	'ꟈ': "d", 'Ꟊ': "S", 'ꟊ': "s", 'Ꟍ': "S", 'ꟍ': "s", 'Ꟑ': "G", 'ꟑ': "g", 'Ꟗ': "S", 'ꟗ': "s", 'Ꟙ': "S",
+	'ꟙ': "s", '꟱': "S", 'ꟲ': "C", 'ꟳ': "F", 'ꟴ': "Q", 'ꟸ': "H",
+	// Latin Extended-E
+	'ꬲ': "e", 'ꬴ': "e", 'ꬵ': "f", 'ꬶ': "g", 'ꬸ': "l", 'ꬹ': "l", 'ꬺ': "m", 'ꬻ': "n", 'ꬼ': "n", 'ꬽ': "o",
+	'ꬾ': "o", 'ꬿ': "o", 'ꭅ': "r", 'ꭆ': "r", 'ꭇ': "r", 'ꭈ': "rr", 'ꭉ': "r", 'ꭊ': "rr", 'ꭋ': "r", 'ꭌ': "r",
+	'ꭎ': "u", 'ꭐ': "ui", 'ꭒ': "u", 'ꭖ': "x", 'ꭗ': "x", 'ꭘ': "x", 'ꭙ': "x", 'ꭚ': "y", 'ꭞ': "l", 'ꭟ': "u",
+	'ꭢ': "oe", 'ꭣ': "uo", 'ꭦ': "dz", 'ꭧ': "ts",
+}
+
+// LatinFold folds Latin text to ASCII. Besides removing the accents as ASCII does, it folds the letters
+// with no decomposition (ex: ł, ø, đ) and expands ligatures (ex: "Straße" = Strasse, "œuvre" = oeuvre).
+type LatinFold struct {
+	folding        map[rune]string
+	preserveLength bool
+}
+
+func NewLatinFold() *LatinFold {
+	return &LatinFold{folding: latinFolding}
+}
+
+// NewLengthPreservingLatinFold creates a LatinFold that replaces every rune with exactly one rune,
+// so the rune positions of the text are kept. Expansions keep only their first letter (ex: ß = s) and
+// combining marks written as separate runes are left in place.
+func NewLengthPreservingLatinFold() *LatinFold {
+	return &LatinFold{folding: latinFolding, preserveLength: true}
+}
+
+func (l LatinFold) Transform(s string) (string, error) {
+	if l.preserveLength {
+		return l.foldRunes(s), nil
+	}
+	decomposed := norm.NFD.String(s)
+	var b strings.Builder
+	b.Grow(len(decomposed))
+	for _, r := range decomposed {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if v, ok := l.folding[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String()), nil
+}
+
+// foldRunes folds each Latin rune of s into a single rune.
+func (l LatinFold) foldRunes(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if unicode.Is(unicode.Latin, r) {
+			// the base letter is the first rune of the decomposition
+			for _, base := range norm.NFD.String(string(r)) {
+				r = base
+				break
+			}
+			if v, ok := l.folding[r]; ok {
+				r, _ = utf8.DecodeRuneInString(v)
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package gomtch
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minLineSegment is the number of lines a segment read from the lines must have.
+const minLineSegment = 2
+
+var lineEncodings = []SegmentEncoding{SegmentAcrostic, SegmentTelestich, SegmentVertical}
+
+// WithLineReading reads the words hidden in the lines of the text as Segments: the first letter of
+// each line (SegmentAcrostic), the last one (SegmentTelestich) and the sequential lines of a single
+// character (SegmentVertical). Without readings all of them are read. Each segment has a token per
+// line, so the tokens found are joined as any spaced word and the Lines of the Match tell the lines
+// they were written in. It runs at the analysis stage, after the markup was extracted.
+func WithLineReading(readings ...SegmentEncoding) Option {
+	return func(d *Document) {
+		if len(readings) == 0 {
+			readings = lineEncodings
+		}
+		for _, r := range readings {
+			if !segmentEncodingIn(r, lineEncodings) {
+				d.optError = fmt.Errorf("%s is not read from the lines", r)
+				return
+			}
+		}
+		d.addStep(StageAnalysis, "WithLineReading", func(d *Document) {
+			for _, r := range readings {
+				d.addSegments(lineSegments(d.Text, r))
+			}
+		})
+	}
+}
+
+func segmentEncodingIn(e SegmentEncoding, encodings []SegmentEncoding) bool {
+	for _, v := range encodings {
+		if v == e {
+			return true
+		}
+	}
+	return false
+}
+
+// lineSegments returns the segments read from the lines of the text and the Spans of their tokens.
+func lineSegments(text string, encoding SegmentEncoding) ([]Segment, [][]Span) {
+	var segments []Segment
+	var spans [][]Span
+	current := Segment{Encoding: encoding}
+	var source, tokens []string
+	var tokenSpans []Span
+	flush := func() {
+		if len(current.Lines) >= minLineSegment {
+			current.Source = strings.Join(source, "")
+			current.Text = strings.Join(tokens, " ")
+			segments = append(segments, current)
+			spans = append(spans, tokenSpans)
+		}
+		current = Segment{Encoding: encoding}
+		source, tokens, tokenSpans = nil, nil, nil
+	}
+	offset := 0
+	for number, line := range strings.Split(text, "\n") {
+		at, r, ok := lineRune(line, encoding)
+		switch {
+		case ok:
+			if current.Lines == nil {
+				current.Offset = offset + at
+			}
+			current.Lines = append(current.Lines, number+1)
+			source = append(source, string(r))
+			tokens = append(tokens, string(r))
+			tokenSpans = append(tokenSpans, Span{Start: offset + at, End: offset + at + utf8.RuneLen(r)})
+		case encoding == SegmentVertical && strings.TrimSpace(line) != "":
+			// a longer line ends the vertical word
+			flush()
+		}
+		offset += len(line) + 1
+	}
+	flush()
+	return segments, spans
+}
+
+// lineRune returns the rune of the line read by the encoding and its byte offset in the line.
+func lineRune(line string, encoding SegmentEncoding) (int, rune, bool) {
+	switch encoding {
+	case SegmentAcrostic:
+		for i, r := range line {
+			if unicode.IsLetter(r) || unicode.IsNumber(r) {
+				return i, r, true
+			}
+		}
+	case SegmentTelestich:
+		for end := len(line); end > 0; {
+			r, size := utf8.DecodeLastRuneInString(line[:end])
+			end -= size
+			if unicode.IsLetter(r) || unicode.IsNumber(r) {
+				return end, r, true
+			}
+		}
+	case SegmentVertical:
+		trimmed := strings.TrimSpace(line)
+		if r, size := utf8.DecodeRuneInString(trimmed); size != 0 && size == len(trimmed) {
+			return strings.Index(line, trimmed), r, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Lines returns the numbers of the lines the tokens of the Match were written in, when it was found
+// in a Segment read from the lines of the text.
+func (m Match) Lines() []int {
+	if m.Segment == nil || m.Segment.Lines == nil || m.Start < 0 || m.End > len(m.Segment.Lines) {
+		return nil
+	}
+	return m.Segment.Lines[m.Start:m.End]
+}
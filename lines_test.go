@@ -0,0 +1,69 @@
+package gomtch
+
+import (
+	"reflect"
+	"testing"
+)
+
+const acrosticText = "Compre agora\nOferta do dia\nRapidez na entrega\nPague no pix\n" +
+	"\nOs melhores preços\nRecebe em casa\nAcesse o site"
+
+func TestWithLineReading(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		readings []SegmentEncoding
+		want     []Segment
+	}{
+		{"acrostic", acrosticText, []SegmentEncoding{SegmentAcrostic}, []Segment{{Encoding: SegmentAcrostic,
+			Offset: 0, Source: "CORPORA", Text: "C O R P O R A", Lines: []int{1, 2, 3, 4, 6, 7, 8}}}},
+		{"telestich", "- bac\n- abc!\n  \n-  xyz  ", []SegmentEncoding{SegmentTelestich}, []Segment{{
+			Encoding: SegmentTelestich, Offset: 4, Source: "ccz", Text: "c c z", Lines: []int{1, 2, 4}}}},
+		{"vertical", "compre\nc\no\n\nc\na\nhoje\nx\ny", []SegmentEncoding{SegmentVertical}, []Segment{
+			{Encoding: SegmentVertical, Offset: 7, Source: "coca", Text: "c o c a", Lines: []int{2, 3, 5, 6}},
+			{Encoding: SegmentVertical, Offset: 21, Source: "xy", Text: "x y", Lines: []int{8, 9}}}},
+		{"singleLine", "compre cocaína", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, WithLineReading(tt.readings...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.Segments(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Segments() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if _, err := NewDocument("", WithLineReading(SegmentBase64)); err == nil {
+		t.Error("WithLineReading(SegmentBase64) error = nil")
+	}
+}
+
+func TestWithLineReading_Find(t *testing.T) {
+	d, err := NewDocument("oi\n"+acrosticText+"\nbjs", WithLineReading(), WithSetLower())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern, err := NewDocument("corpora")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := d.Find(pattern)
+	if len(found) != 1 {
+		t.Fatalf("Find() = %+v", found)
+	}
+	m := found[0]
+	if m.Segment == nil || m.Segment.Encoding != SegmentAcrostic || string(m.Sequence) != "c o r p o r a" {
+		t.Fatalf("Find() = %+v", m)
+	}
+	if got := m.Lines(); !reflect.DeepEqual(got, []int{2, 3, 4, 5, 7, 8, 9}) {
+		t.Errorf("Lines() = %v", got)
+	}
+	if got := m.Provenance(); got != "found in acrostic segment at offset 0" {
+		t.Errorf("Provenance() = %v", got)
+	}
+	if got := d.Scan(pattern); !reflect.DeepEqual(got, Matches{0: []rune("c o r p o r a")}) {
+		t.Errorf("Scan() = %v", got)
+	}
+}
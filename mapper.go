@@ -13,58 +13,49 @@ type Mapping map[string][]int
 func NewMappingFromTokens(tokens []string) Mapping {
 	m := Mapping{}
 	var cntr int
-	var cntrRef int
 	for _, t := range tokens {
-		cntrRef = cntr
-		startSpecial := getStartSpecial([]rune(t))
-		if startSpecial != nil {
-			for _, s := range startSpecial {
-				m = m.AddIndex(string(s), cntr)
-				cntr++
-			}
-			t = t[len(startSpecial):]
-			cntrRef = cntr
+		startSpecial, word, endSpecial := splitSpecials(t)
+		for _, s := range startSpecial {
+			m = m.AddIndex(string(s), cntr)
+			cntr++
 		}
-		endSpecial := getEndSpecial([]rune(t))
-		if endSpecial != nil {
-			cntrRef = cntr
-			for _, s := range endSpecial {
-				cntr++
-				m = m.AddIndex(string(s), cntr)
-			}
-			t = t[:len(t)-len(endSpecial)]
+		m = m.AddIndex(word, cntr)
+		for i, s := range endSpecial {
+			m = m.AddIndex(string(s), cntr+i+1)
 		}
-		m = m.AddIndex(t, cntrRef)
-		cntr++
+		cntr += len(endSpecial) + 1
 	}
 	return m
 }
 
+// Map orders the words of the Mapping by their indexes and returns them as Tokens.
+// A word seen before keeps the id of its first occurrence. It runs in linear time
+// over the number of indexes, stopping at the first index missing from the Mapping.
 func (m Mapping) Map() Tokens {
 	tokens := Tokens{
 		Values: map[int][]rune{},
 	}
-	reference := map[string]int{}
-	var next int
-Outer:
-	for {
-		for word, indexes := range m.Items() {
-			for _, index := range indexes {
-				if index == next {
-					if id, ok := reference[word]; ok {
-						tokens.Ids = append(tokens.Ids, id)
-						next++
-						continue Outer
-					}
-					reference[word] = next
-					tokens.Values[next] = []rune(word)
-					tokens.Ids = append(tokens.Ids, next)
-					next++
-					continue Outer
-				}
+	var size int
+	for _, indexes := range m {
+		size += len(indexes)
+	}
+	// an index bigger than the number of indexes can't be reached without a gap
+	words := make([]string, size)
+	found := make([]bool, size)
+	for word, indexes := range m {
+		for _, index := range indexes {
+			if index >= 0 && index < size {
+				words[index] = word
+				found[index] = true
 			}
 		}
-		break
+	}
+	reference := map[string]int{}
+	for next, word := range words {
+		if !found[next] {
+			break
+		}
+		tokens.add(word, next, reference)
 	}
 	return tokens
 }
@@ -82,6 +73,20 @@ func (m Mapping) AddIndex(v string, index int) Mapping {
 	return m
 }
 
+// splitSpecials separates the special characters at the start and at the end of the token
+// from the word between them. A token without letters or numbers is returned as the word.
+// The special characters at the end are returned from the last one to the first.
+func splitSpecials(token string) ([]rune, string, []rune) {
+	runes := []rune(token)
+	startSpecial := getStartSpecial(runes)
+	runes = runes[len(startSpecial):]
+	endSpecial := getEndSpecial(runes)
+	if startSpecial == nil && endSpecial == nil {
+		return nil, token, nil
+	}
+	return startSpecial, string(runes[:len(runes)-len(endSpecial)]), endSpecial
+}
+
 func getStartSpecial(token []rune) []rune {
 	var special []rune
 	for _, r := range token {
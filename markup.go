@@ -0,0 +1,438 @@
+package gomtch
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MarkupSegment is a run of text copied from the source by WithMarkdownParsing or WithBBCodeParsing:
+// the Length bytes at Offset in the extracted text are the ones at Source in the source text.
+type MarkupSegment struct {
+	Offset int
+	Source int
+	Length int
+}
+
+// MarkupLink is a link found by WithMarkdownParsing or WithBBCodeParsing. Text is the text of the
+// link as extracted at Offset, the URL is not part of the extracted text unless it is the link text.
+type MarkupLink struct {
+	Offset int
+	Text   string
+	URL    string
+}
+
+// MarkupSegments returns the runs of text extracted by WithMarkdownParsing or WithBBCodeParsing
+// with where they were in the source text.
+func (d Document) MarkupSegments() []MarkupSegment {
+	return d.markupSegments
+}
+
+// Links returns the links found by WithMarkdownParsing or WithBBCodeParsing.
+func (d Document) Links() []MarkupLink {
+	return d.links
+}
+
+// markupWriter builds the text extracted from a markup recording where each run of it came from.
+type markupWriter struct {
+	source   string
+	b        *textBuilder
+	segments []MarkupSegment
+	links    []MarkupLink
+}
+
+// copy appends source[from:to] to the extracted text.
+func (w *markupWriter) copy(from, to int) {
+	if from >= to {
+		return
+	}
+	offset := w.b.Len()
+	w.b.write(w.source[from:to], from, to)
+	if last := len(w.segments) - 1; last >= 0 &&
+		w.segments[last].Offset+w.segments[last].Length == offset &&
+		w.segments[last].Source+w.segments[last].Length == from {
+		w.segments[last].Length += to - from
+		return
+	}
+	w.segments = append(w.segments, MarkupSegment{Offset: offset, Source: from, Length: to - from})
+}
+
+// boundary ends the current line of the extracted text.
+func (w *markupWriter) boundary() {
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		w.b.insert("\n")
+	}
+}
+
+func (w *markupWriter) apply(d *Document) {
+	w.b.setText(d)
+	d.markupSegments = append(d.markupSegments, w.segments...)
+	d.links = append(d.links, w.links...)
+}
+
+// WithMarkdownParsing extracts the plain text of a Markdown document. The emphasis, code, heading,
+// quote and list markers are dropped ("**co**rpora" = corpora), links keep only their text
+// ("[corpora](http://x.com)" = corpora) and their URLs are reported by the Links of the Document.
+// The MarkupSegments of the Document map the text extracted back to the source.
+func WithMarkdownParsing() Option {
+	return func(d *Document) {
+		d.addStep(StageMarkup, "WithMarkdownParsing", func(d *Document) {
+			w := &markupWriter{source: d.Text, b: d.newTextBuilder(len(d.Text))}
+			parseMarkdown(w)
+			w.apply(d)
+		})
+	}
+}
+
+func parseMarkdown(w *markupWriter) {
+	source := w.source
+	var fence string
+	for start := 0; start < len(source); {
+		end := strings.IndexByte(source[start:], '\n')
+		next := len(source)
+		if end < 0 {
+			end = len(source)
+		} else {
+			end += start
+			next = end + 1
+		}
+		line := source[start:end]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			} else {
+				w.copy(start, end)
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		case isMarkdownRule(trimmed):
+		default:
+			from, to := markdownBlockContent(source, start, end)
+			parseMarkdownInline(w, from, to)
+		}
+		if end < len(source) {
+			w.copy(end, next)
+		}
+		start = next
+	}
+}
+
+// isMarkdownRule reports whether the line is a thematic break (---, ***, ___) or a setext heading underline.
+func isMarkdownRule(line string) bool {
+	compact := strings.Replace(line, " ", "", -1)
+	if len(compact) < 3 && !(compact != "" && compact[0] == '=') {
+		return false
+	}
+	return strings.Trim(compact, "-") == "" || strings.Trim(compact, "*") == "" ||
+		strings.Trim(compact, "_") == "" || strings.Trim(compact, "=") == ""
+}
+
+// markdownBlockContent skips the quote, heading and list markers of the line source[start:end].
+func markdownBlockContent(source string, start, end int) (int, int) {
+	i := start
+	skipSpaces := func() {
+		for i < end && (source[i] == ' ' || source[i] == '\t') {
+			i++
+		}
+	}
+	skipSpaces()
+	for i < end && source[i] == '>' {
+		i++
+		skipSpaces()
+	}
+	switch {
+	case i < end && source[i] == '#':
+		j := i
+		for j < end && source[j] == '#' {
+			j++
+		}
+		if j-i <= 6 && (j == end || source[j] == ' ' || source[j] == '\t') {
+			i = j
+			skipSpaces()
+			// closing sequence of the heading
+			e := strings.TrimRight(source[i:end], " \t")
+			if c := strings.TrimRight(e, "#"); len(c) < len(e) && (c == "" || strings.HasSuffix(c, " ")) {
+				end = i + len(strings.TrimRight(c, " "))
+			}
+		}
+	case i+1 < end && strings.IndexByte("-*+", source[i]) >= 0 && (source[i+1] == ' ' || source[i+1] == '\t'):
+		i++
+		skipSpaces()
+	default:
+		j := i
+		for j < end && j-i < 9 && source[j] >= '0' && source[j] <= '9' {
+			j++
+		}
+		if j > i && j+1 < end && (source[j] == '.' || source[j] == ')') && (source[j+1] == ' ' || source[j+1] == '\t') {
+			i = j + 1
+			skipSpaces()
+		}
+	}
+	return i, end
+}
+
+// parseMarkdownInline extracts the text of source[from:to] dropping the inline markers.
+func parseMarkdownInline(w *markupWriter, from, to int) {
+	source := w.source
+	for i := from; i < to; {
+		c := source[i]
+		switch {
+		case c == '\\' && i+1 < to && isASCIIPunct(source[i+1]):
+			w.copy(i+1, i+2)
+			i += 2
+			continue
+		case c == '`':
+			n := runLength(source, i, to)
+			if closing := strings.Index(source[i+n:to], source[i:i+n]); closing >= 0 {
+				w.copy(i+n, i+n+closing)
+				i += 2*n + closing
+				continue
+			}
+			w.copy(i, i+n)
+			i += n
+			continue
+		case c == '!' && i+1 < to && source[i+1] == '[':
+			if next, ok := parseMarkdownLink(w, i+1, to); ok {
+				i = next
+				continue
+			}
+		case c == '[':
+			if next, ok := parseMarkdownLink(w, i, to); ok {
+				i = next
+				continue
+			}
+		case c == '<':
+			if closing := strings.IndexByte(source[i:to], '>'); closing > 1 {
+				url := source[i+1 : i+closing]
+				if !strings.ContainsAny(url, " \t<") && strings.ContainsAny(url, ":@") {
+					w.links = append(w.links, MarkupLink{Offset: w.b.Len(), Text: url, URL: url})
+					w.copy(i+1, i+closing)
+					i += closing + 1
+					continue
+				}
+			}
+		case c == '*' || c == '_' || c == '~':
+			n := runLength(source, i, to)
+			if isEmphasisMarker(source, i, i+n, from, to) {
+				i += n
+				continue
+			}
+			w.copy(i, i+n)
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(source[i:to])
+		w.copy(i, i+size)
+		i += size
+	}
+}
+
+// parseMarkdownLink parses the link (or image) starting at the bracket at i. The text of the link is
+// extracted and its URL recorded. It returns the index after the link.
+func parseMarkdownLink(w *markupWriter, i, to int) (int, bool) {
+	source := w.source
+	depth := 0
+	closing := -1
+	for j := i; j < to && closing < 0; j++ {
+		switch source[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = j
+			}
+		}
+	}
+	if closing < 0 || closing+1 >= to {
+		return 0, false
+	}
+	var url string
+	var next int
+	switch source[closing+1] {
+	case '(':
+		end := strings.IndexByte(source[closing+1:to], ')')
+		if end < 0 {
+			return 0, false
+		}
+		next = closing + 1 + end + 1
+		if fields := strings.Fields(source[closing+2 : next-1]); len(fields) > 0 {
+			url = strings.Trim(fields[0], "<>")
+		}
+	case '[':
+		// reference link, the URL is defined somewhere else
+		end := strings.IndexByte(source[closing+1:to], ']')
+		if end < 0 {
+			return 0, false
+		}
+		next = closing + 1 + end + 1
+	default:
+		return 0, false
+	}
+	offset := w.b.Len()
+	parseMarkdownInline(w, i+1, closing)
+	w.links = append(w.links, MarkupLink{Offset: offset, Text: w.b.String()[offset:], URL: url})
+	return next, true
+}
+
+func runLength(source string, i, to int) int {
+	n := 1
+	for i+n < to && source[i+n] == source[i] {
+		n++
+	}
+	return n
+}
+
+// isEmphasisMarker reports whether the run source[i:j] of *, _ or ~ opens or closes an emphasis,
+// that is, it is not surrounded by spaces. An _ between letters and a single ~ are not markers.
+func isEmphasisMarker(source string, i, j, from, to int) bool {
+	if source[i] == '~' && j-i < 2 {
+		return false
+	}
+	var prev, next rune = ' ', ' '
+	if i > from {
+		prev, _ = utf8.DecodeLastRuneInString(source[from:i])
+	}
+	if j < to {
+		next, _ = utf8.DecodeRuneInString(source[j:to])
+	}
+	if source[i] == '_' && isAlphanumeric(prev) && isAlphanumeric(next) {
+		return false
+	}
+	return !unicode.IsSpace(prev) || !unicode.IsSpace(next)
+}
+
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// indexFoldASCII returns the index of the first instance of sub in s, ignoring the case of the ASCII
+// letters, or -1. sub must be lower case. Unlike lowering s first the index is one of s itself.
+func indexFoldASCII(s, sub string) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		j := 0
+		for j < len(sub) && unicode.ToLower(rune(s[i+j])) == rune(sub[j]) {
+			j++
+		}
+		if j == len(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+var (
+	bbCodeBlocks = []string{"quote", "code", "list", "*", "center", "left", "right", "table", "tr", "td",
+		"indent", "spoiler", "hr"}
+	bbCodeInline = []string{"b", "i", "u", "s", "color", "size", "font", "sup", "sub", "highlight", "noparse"}
+)
+
+// WithBBCodeParsing extracts the plain text of a BBCode document. The formatting tags are dropped
+// ("[b]co[/b]rpora" = corpora), block tags (ex: quote, list) become new lines, links keep only their
+// text and their URLs are reported by the Links of the Document, as are the images.
+// The MarkupSegments of the Document map the text extracted back to the source.
+func WithBBCodeParsing() Option {
+	return func(d *Document) {
+		d.addStep(StageMarkup, "WithBBCodeParsing", func(d *Document) {
+			w := &markupWriter{source: d.Text, b: d.newTextBuilder(len(d.Text))}
+			parseBBCode(w)
+			w.apply(d)
+		})
+	}
+}
+
+type bbCodeTag struct {
+	name    string
+	value   string
+	closing bool
+	size    int
+}
+
+// parseBBCodeTag parses the tag starting at the bracket at i.
+func parseBBCodeTag(source string, i int) (bbCodeTag, bool) {
+	end := strings.IndexByte(source[i:], ']')
+	if end < 0 {
+		return bbCodeTag{}, false
+	}
+	tag := bbCodeTag{size: end + 1}
+	content := source[i+1 : i+end]
+	if strings.HasPrefix(content, "/") {
+		tag.closing = true
+		content = content[1:]
+	}
+	if eq := strings.IndexByte(content, '='); eq >= 0 && !tag.closing {
+		tag.value = strings.Trim(content[eq+1:], `"'`)
+		content = content[:eq]
+	}
+	tag.name = strings.ToLower(content)
+	if tag.name == "url" || tag.name == "email" || tag.name == "img" ||
+		stringIn(tag.name, bbCodeBlocks) || stringIn(tag.name, bbCodeInline) {
+		return tag, true
+	}
+	return bbCodeTag{}, false
+}
+
+func parseBBCode(w *markupWriter) {
+	source := w.source
+	var openLinks []MarkupLink
+	for i := 0; i < len(source); {
+		if source[i] != '[' {
+			_, size := utf8.DecodeRuneInString(source[i:])
+			w.copy(i, i+size)
+			i += size
+			continue
+		}
+		tag, ok := parseBBCodeTag(source, i)
+		if !ok {
+			w.copy(i, i+1)
+			i++
+			continue
+		}
+		i += tag.size
+		switch {
+		case (tag.name == "url" || tag.name == "email" || tag.name == "img") && (tag.value == "" || tag.name == "img") &&
+			!tag.closing:
+			// the content is the URL
+			closing := indexFoldASCII(source[i:], "[/"+tag.name+"]")
+			if closing < 0 {
+				closing = len(source) - i
+			}
+			url := source[i : i+closing]
+			link := MarkupLink{Offset: w.b.Len(), URL: url}
+			if tag.name != "img" {
+				link.Text = url
+				w.copy(i, i+closing)
+			}
+			w.links = append(w.links, link)
+			i += closing + len(tag.name) + 3
+			if i > len(source) {
+				i = len(source)
+			}
+		case tag.name == "url" || tag.name == "email":
+			if !tag.closing {
+				openLinks = append(openLinks, MarkupLink{Offset: w.b.Len(), URL: tag.value})
+				continue
+			}
+			if last := len(openLinks) - 1; last >= 0 {
+				link := openLinks[last]
+				openLinks = openLinks[:last]
+				link.Text = w.b.String()[link.Offset:]
+				w.links = append(w.links, link)
+			}
+		case stringIn(tag.name, bbCodeBlocks):
+			w.boundary()
+		}
+	}
+	for _, link := range openLinks {
+		link.Text = w.b.String()[link.Offset:]
+		w.links = append(w.links, link)
+	}
+}
@@ -0,0 +1,110 @@
+package gomtch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithMarkdownParsing(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		wantLinks []MarkupLink
+	}{
+		{"plain", "buy corpora now", "buy corpora now", nil},
+		{"emphasis", "**co**rpora *now* __here__ ~~not~~", "corpora now here not", nil},
+		{"notEmphasis", "2 * 3 snake_case ~1", "2 * 3 snake_case ~1", nil},
+		{"code", "buy `corpora` now", "buy corpora now", nil},
+		{"escape", `\*corpora\*`, "*corpora*", nil},
+		{"heading", "# buy\n## corpora ##\n#hashtag", "buy\ncorpora\n#hashtag", nil},
+		{"quoteAndLists", "> buy\n- corpora\n* now\n1. here", "buy\ncorpora\nnow\nhere", nil},
+		{"rules", "buy\n---\nnow\n===", "buy\n\nnow\n", nil},
+		{"fence", "```go\nco*rp*ora\n```", "\nco*rp*ora\n", nil},
+		{"link", "buy [**co**rpora](http://x.com \"title\") now", "buy corpora now",
+			[]MarkupLink{{Offset: 4, Text: "corpora", URL: "http://x.com"}}},
+		{"image", "![corpora](/c.png)", "corpora", []MarkupLink{{Offset: 0, Text: "corpora", URL: "/c.png"}}},
+		{"reference", "[corpora][1]", "corpora", []MarkupLink{{Offset: 0, Text: "corpora"}}},
+		{"autolink", "see <http://x.com>", "see http://x.com",
+			[]MarkupLink{{Offset: 4, Text: "http://x.com", URL: "http://x.com"}}},
+		{"notLink", "[corpora] a < b", "[corpora] a < b", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, WithMarkdownParsing())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Text != tt.want {
+				t.Errorf("Text = %q, want %q", d.Text, tt.want)
+			}
+			if got := d.Links(); !reflect.DeepEqual(got, tt.wantLinks) {
+				t.Errorf("Links() = %v, want %v", got, tt.wantLinks)
+			}
+		})
+	}
+}
+
+func TestWithBBCodeParsing(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		wantLinks []MarkupLink
+	}{
+		{"plain", "buy [sic] corpora", "buy [sic] corpora", nil},
+		{"formatting", "[b]co[/b]rpora [COLOR=red]now[/COLOR] [size=1]x[/size]", "corpora now x", nil},
+		{"blocks", "[quote=bob]buy[/quote][list][*]corpora[*]now[/list]", "buy\ncorpora\nnow\n", nil},
+		{"urlWithValue", "[url=http://x.com]co[b]rpo[/b]ra[/url] now", "corpora now",
+			[]MarkupLink{{Offset: 0, Text: "corpora", URL: "http://x.com"}}},
+		{"url", "see [url]http://x.com[/url]", "see http://x.com",
+			[]MarkupLink{{Offset: 4, Text: "http://x.com", URL: "http://x.com"}}},
+		{"image", "buy [img]http://x.com/c.png[/img]now", "buy now",
+			[]MarkupLink{{Offset: 4, URL: "http://x.com/c.png"}}},
+		{"unclosed", "[url=http://x.com]corpora", "corpora",
+			[]MarkupLink{{Offset: 0, Text: "corpora", URL: "http://x.com"}}},
+		{"urlUpperCase", "[URL]http://x.com[/Url] now", "http://x.com now",
+			[]MarkupLink{{Offset: 0, Text: "http://x.com", URL: "http://x.com"}}},
+		// the lower case of these letters is shorter or longer than them
+		{"urlShorterLower", "[url]ȺȺȺȺȺȺȺ[/url]", "ȺȺȺȺȺȺȺ",
+			[]MarkupLink{{Offset: 0, Text: "ȺȺȺȺȺȺȺ", URL: "ȺȺȺȺȺȺȺ"}}},
+		{"urlLongerLower", "[url]İİİİ[/url] now", "İİİİ now",
+			[]MarkupLink{{Offset: 0, Text: "İİİİ", URL: "İİİİ"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, WithBBCodeParsing())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Text != tt.want {
+				t.Errorf("Text = %q, want %q", d.Text, tt.want)
+			}
+			if got := d.Links(); !reflect.DeepEqual(got, tt.wantLinks) {
+				t.Errorf("Links() = %v, want %v", got, tt.wantLinks)
+			}
+		})
+	}
+}
+
+func TestMarkupSegments(t *testing.T) {
+	text := "buy [b]co[/b]rpora"
+	d, err := NewDocument(text, WithBBCodeParsing(), WithOffsetTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MarkupSegment{{Offset: 0, Source: 0, Length: 4}, {Offset: 4, Source: 7, Length: 2},
+		{Offset: 6, Source: 13, Length: 5}}
+	if got := d.MarkupSegments(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MarkupSegments() = %v, want %v", got, want)
+	}
+	for _, s := range d.MarkupSegments() {
+		if d.Text[s.Offset:s.Offset+s.Length] != text[s.Source:s.Source+s.Length] {
+			t.Errorf("segment %v does not match the source", s)
+		}
+	}
+	spans := d.TokenSpans()
+	if got := text[spans[1].Start:spans[1].End]; got != "co[/b]rpora" {
+		t.Errorf("TokenSpans() = %q", got)
+	}
+}
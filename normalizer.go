@@ -0,0 +1,135 @@
+package gomtch
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// Normalizer compiles a set of options once so they can be applied to many texts.
+// The options are called only when the Normalizer is created, each call to Document
+// runs the resulting normalization steps over a fresh copy of the configuration.
+// A Normalizer is safe for concurrent use as long as the Transformers given to it are.
+type Normalizer struct {
+	template Document
+	steps    []step
+	err      error
+}
+
+// NewNormalizer calls each option and keeps the resulting configuration.
+// An error returned by an option is reported by every call to Document.
+func NewNormalizer(opts ...Option) *Normalizer {
+	n := &Normalizer{}
+	d := &n.template
+	// Loop through each option
+	for _, opt := range opts {
+		// Call the option giving the template
+		// *Document as the argument
+		opt(d)
+		if d.optError != nil {
+			n.err = d.optError
+			return n
+		}
+	}
+	// The normalization options only registered their steps,
+	// they run in the stage order for each text
+	d.warnings = append(checkSteps(d.steps, d.customOrder), checkMatchScore(d.matchScoreOptions)...)
+	n.steps = orderSteps(d.steps, d.customOrder)
+	d.steps = nil
+	if d.matchScoreFunc == nil {
+		d.matchScoreFunc = minimumMatchScore(defaultMatchScore)
+	}
+	if len(d.matchScoreOptions) == 0 {
+		// the strategies reach the default minimum as they would WithMinimumMatchScore(defaultMatchScore)
+		d.minimumScore = fixedMinimumScore(defaultMatchScore)
+	}
+	return n
+}
+
+// Document normalizes the text and returns it as a Document.
+func (n *Normalizer) Document(text string) (*Document, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+	d := n.template
+	if d.strictUTF8 {
+		if err := checkUTF8(text); err != nil {
+			return nil, err
+		}
+	}
+	d.Text = text
+	d.original = text
+	if d.offsetTracking {
+		d.offsets, d.offsetsText = newOffsetMap(text), text
+	}
+	d.runSteps(n.steps)
+	if d.optError != nil {
+		return nil, d.optError
+	}
+	if d.Tokens == nil {
+		d.Tokens = d.tokenize(defaultTokenizer)
+	}
+	d.cache()
+	for i, s := range d.segments {
+		tokens, signals, err := n.segmentTokens(s.Text)
+		if err != nil {
+			return nil, err
+		}
+		d.segmentTokens = append(d.segmentTokens, tokens)
+		for _, signal := range signals {
+			signal.Segment = &d.segments[i]
+			d.addSignal(signal)
+		}
+	}
+	return &d, nil
+}
+
+// segmentTokens normalizes a decoded segment with the steps that run after the decode stage and
+// returns the signals they found, with their offsets in the text of the segment.
+func (n *Normalizer) segmentTokens(text string) (Tokens, []Signal, error) {
+	d := n.template
+	d.Text = text
+	if d.offsetTracking {
+		d.offsets, d.offsetsText = newOffsetMap(text), text
+	}
+	var steps []step
+	for _, s := range n.steps {
+		if s.stage != StageDecode {
+			steps = append(steps, s)
+		}
+	}
+	d.runSteps(steps)
+	if d.optError != nil {
+		return Tokens{}, nil, d.optError
+	}
+	if d.Tokens == nil {
+		d.Tokens = d.tokenize(defaultTokenizer)
+	}
+	return NewTokens(d.Tokens), d.signals, nil
+}
+
+// DocumentFromReader reads all the text and normalizes it as a Document. The text is transcoded
+// to UTF-8 first when its charset was declared by WithCharset or found by WithCharsetDetection.
+func (n *Normalizer) DocumentFromReader(text io.Reader) (*Document, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+	b, err := ioutil.ReadAll(text)
+	if err != nil {
+		return nil, err
+	}
+	s, name, err := n.template.decodeCharset(b)
+	if err != nil {
+		return nil, err
+	}
+	d, err := n.Document(s)
+	if err != nil {
+		return nil, err
+	}
+	d.charset = name
+	return d, nil
+}
+
+// Warnings returns the conflicts found between the options given to the Normalizer.
+func (n *Normalizer) Warnings() []Warning {
+	return n.template.warnings
+}
@@ -0,0 +1,123 @@
+package gomtch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestNormalizer_Document(t *testing.T) {
+	n := NewNormalizer(
+		WithHMTLParsing(),
+		WithSetLower(),
+		WithTransform(NewASCII()),
+		WithSequentialEqualCharsRemoval(),
+		WithReplacer(regexp.MustCompile(`[!?.]`), " "),
+		WithMinimumMatchScore(60))
+	tests := []struct {
+		name   string
+		text   string
+		want   string
+		tokens []string
+	}{
+		{"html", "<p>COCAÍNA!</p>", "cocaina ", []string{"cocaina"}},
+		{"sequentialChars", "cocaaaaína", "cocaina", []string{"cocaina"}},
+		{"sequentialCharsAgain", "cocaaaaína", "cocaina", []string{"cocaina"}},
+		{"numbers", "iphone 11", "iphone 11", []string{"iphone", "11"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := n.Document(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Text != tt.want || !reflect.DeepEqual(got.Tokens, tt.tokens) {
+				t.Errorf("Document() = %q %q, want %q %q", got.Text, got.Tokens, tt.want, tt.tokens)
+			}
+		})
+	}
+}
+
+func TestNormalizer_DocumentReuseOption(t *testing.T) {
+	opt := WithSequentialEqualCharsRemoval()
+	for _, text := range []string{"caaasa", "caaasa", "reeeal"} {
+		first, err := NewDocument(text, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := NewDocument(text, WithSequentialEqualCharsRemoval())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first.Text != second.Text {
+			t.Errorf("reused option = %q, want %q", first.Text, second.Text)
+		}
+	}
+}
+
+func TestNormalizer_DocumentConcurrent(t *testing.T) {
+	n := NewNormalizer(WithSetLower(), WithTransform(NewASCII()), WithSequentialEqualCharsRemoval())
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				d, err := n.Document(fmt.Sprintf("Caaançããão %d", i))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if want := fmt.Sprintf("cancao %d", i); d.Text != want {
+					t.Errorf("Document() = %q, want %q", d.Text, want)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNormalizer_DocumentError(t *testing.T) {
+	wantErr := fmt.Errorf("broken option")
+	n := NewNormalizer(func(d *Document) {
+		d.optError = wantErr
+	})
+	if _, err := n.Document("cocaina"); err != wantErr {
+		t.Errorf("Document() error = %v, want %v", err, wantErr)
+	}
+}
+
+func BenchmarkNormalizer_Document(b *testing.B) {
+	f, err := os.Open("testdata/sertoes.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	text, err := ioutil.ReadAll(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := []Option{WithSetLower(), WithTransform(NewASCII()), WithSequentialEqualCharsRemoval()}
+	b.Run("NewDocument", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewDocument(string(text), opts...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Normalizer", func(b *testing.B) {
+		n := NewNormalizer(opts...)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := n.Document(string(text)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -1,98 +1,214 @@
 package gomtch
 
 import (
-	"bytes"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/jdkato/prose/tokenize"
+	"golang.org/x/net/html"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
+var whiteSpaces = regexp.MustCompile(`\s+`)
+
 type Option func(*Document)
 
 func WithHMTLParsing() Option {
 	return func(d *Document) {
-		// Load the HTML document
-		doc, err := goquery.NewDocumentFromReader(strings.NewReader(d.Text))
-		if err != nil {
-			d.optError = err
-			return
-		}
-		d.Text = doc.Text()
+		d.addStep(StageMarkup, "WithHMTLParsing", func(d *Document) {
+			// Load the HTML document
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(d.Text))
+			if err != nil {
+				d.optError = err
+				return
+			}
+			if d.offsets == nil {
+				d.Text = doc.Text()
+				return
+			}
+			// the texts of the nodes in the order Text reads them
+			b, source := d.newTextBuilder(len(d.Text)), d.newHTMLSource()
+			var walk func(n *html.Node)
+			walk = func(n *html.Node) {
+				if n.Type == html.TextNode {
+					source.write(b, n.Data)
+				}
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					walk(c)
+				}
+			}
+			for _, n := range doc.Nodes {
+				walk(n)
+			}
+			source.setText(d, b, doc.Text())
+		})
 	}
 }
 
 func WithTransform(t Transformer) Option {
 	return func(d *Document) {
-		s, err := t.Transform(d.Text)
-		if err != nil {
-			d.optError = err
-			return
-		}
-		d.Text = s
+		d.addStep(StageUnicodeFolding, "WithTransform", func(d *Document) {
+			if err := d.applyLocal(t.Transform); err != nil {
+				d.optError = err
+			}
+		})
 	}
 }
 
 func WithSequentialEqualCharsRemoval() Option {
-	var buf bytes.Buffer
-	var pc rune
 	return func(d *Document) {
-		for i, c := range d.Text {
-			if i == 0 {
-				pc = c
-				buf.WriteRune(c)
-			}
-			if pc == c {
-				if !unicode.IsNumber(pc) {
-					continue
+		d.addStep(StageCleanup, "WithSequentialEqualCharsRemoval", func(d *Document) {
+			// the buffer and the previous char belong to a single run so the
+			// same Option can be used by many Documents
+			buf := d.newTextBuilder(len(d.Text))
+			var pc rune
+			for i, c := range d.Text {
+				_, size := utf8.DecodeRuneInString(d.Text[i:])
+				if i == 0 {
+					pc = c
+					buf.writeRune(c, i, i+size)
+				}
+				if pc == c {
+					if !unicode.IsNumber(pc) {
+						continue
+					}
 				}
+				pc = c
+				buf.writeRune(c, i, i+size)
 			}
-			pc = c
-			buf.WriteRune(c)
-		}
-		d.Text = buf.String()
+			buf.setText(d)
+		})
 	}
 }
 
+func toLower(text string) (string, error) {
+	return strings.ToLower(text), nil
+}
+
+func toUpper(text string) (string, error) {
+	return strings.ToUpper(text), nil
+}
+
 func WithSetLower() Option {
 	return func(d *Document) {
-		d.Text = strings.ToLower(d.Text)
+		d.addStep(StageCaseFolding, "WithSetLower", func(d *Document) {
+			d.applyLocal(toLower)
+		})
 	}
 }
 
 func WithSetUpper() Option {
 	return func(d *Document) {
-		d.Text = strings.ToUpper(d.Text)
+		d.addStep(StageCaseFolding, "WithSetUpper", func(d *Document) {
+			d.applyLocal(toUpper)
+		})
+	}
+}
+
+// WithCaseFolding folds the case of the text following the rules of the language (ex: the Turkish
+// İ and I are lowered to i and ı) and then applies the full Unicode case folding, which also folds
+// the ligatures and the special forms of the letters (ex: "ﬀ" = ff, "ß" = ss, "ς" = σ).
+// It should be given to both the Document scanned and the ones compared to it.
+func WithCaseFolding(tag language.Tag) Option {
+	return func(d *Document) {
+		d.addStep(StageCaseFolding, "WithCaseFolding", func(d *Document) {
+			// a Caser keeps state so each run gets its own
+			d.applyLocal(func(text string) (string, error) {
+				return cases.Fold().String(cases.Lower(tag).String(text)), nil
+			})
+		})
 	}
 }
 
 func WithReplacer(pattern *regexp.Regexp, rep string) Option {
 	return func(d *Document) {
-		d.Text = pattern.ReplaceAllString(d.Text, rep)
+		d.addStep(StageCleanup, "WithReplacer", func(d *Document) {
+			if d.offsets == nil {
+				d.Text = pattern.ReplaceAllString(d.Text, rep)
+				return
+			}
+			b := d.newTextBuilder(len(d.Text))
+			last := 0
+			for _, match := range pattern.FindAllStringSubmatchIndex(d.Text, -1) {
+				b.write(d.Text[last:match[0]], last, match[0])
+				b.write(string(pattern.ExpandString(nil, rep, d.Text, match)), match[0], match[1])
+				last = match[1]
+			}
+			b.write(d.Text[last:], last, len(d.Text))
+			b.setText(d)
+		})
 	}
 }
 
 func WithMinimumMatchScore(score int) Option {
 	return func(d *Document) {
-		d.matchScoreFunc = func(matchScore, wordLength int) bool {
-			return matchScore >= score*wordLength/100
-		}
+		d.setMatchScore("WithMinimumMatchScore", minimumMatchScore(score), nil)
+		d.minimumScore = fixedMinimumScore(score)
+	}
+}
+
+// defaultMatchScore is the minimum match score when no option decides whether the tokens match.
+const defaultMatchScore = 100
+
+// fixedMinimumScore returns the minimum score of the strategies for tokens of any length.
+func fixedMinimumScore(score int) func(int) int {
+	return func(int) int {
+		return score
+	}
+}
+
+func minimumMatchScore(score int) func(int, int) bool {
+	return func(matchScore, wordLength int) bool {
+		return matchScore >= score*wordLength/100
 	}
 }
 
 func WithConditionalMatchScore(f func(int, int) bool) Option {
 	return func(d *Document) {
-		d.matchScoreFunc = f
+		d.setMatchScore("WithConditionalMatchScore", f, nil)
+	}
+}
+
+// setMatchScore records the option deciding whether the tokens match, with a function of the runes
+// matched or a Scorer. Only the last one of these options given takes effect.
+func (d *Document) setMatchScore(name string, f func(int, int) bool, s Scorer) {
+	d.matchScoreOptions = append(d.matchScoreOptions, name)
+	d.matchScoreFunc, d.scorer, d.minimumScore = f, s, nil
+}
+
+// WithCaseInsensitiveMatching makes CompareRune and IsEqual match letters in any case (ex: "Ç" = ç)
+// without changing the text.
+func WithCaseInsensitiveMatching() Option {
+	return func(d *Document) {
+		d.caseInsensitive = true
+	}
+}
+
+// WithTokenizer splits the text in tokens using t. Without it the text is split on white spaces.
+func WithTokenizer(t Tokenizer) Option {
+	return func(d *Document) {
+		d.addStep(StageTokenization, "WithTokenizer", func(d *Document) {
+			d.Tokens = d.tokenize(t)
+		})
 	}
 }
 
+// WithCustomRegexpTokenizer splits the text using a tokenizer of github.com/jdkato/prose.
+// A nil t removes all the white spaces and keeps the text as a single token.
+//
+// Deprecated: use WithTokenizer, which accepts the same tokenizers. The nil behavior is
+// available as WithTokenizer(NewJoinedTokenizer()).
 func WithCustomRegexpTokenizer(t *tokenize.RegexpTokenizer) Option {
 	return func(d *Document) {
-		if t == nil {
-			d.Tokens = []string{regexp.MustCompile(`\s+`).ReplaceAllString(d.Text, "")}
-			return
+		var tokenizer Tokenizer = NewJoinedTokenizer()
+		if t != nil {
+			tokenizer = t
 		}
-		d.Tokens = t.Tokenize(d.Text)
+		d.addStep(StageTokenization, "WithCustomRegexpTokenizer", func(d *Document) {
+			d.Tokens = d.tokenize(tokenizer)
+		})
 	}
 }
@@ -0,0 +1,520 @@
+package gomtch
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// StringForm selects the text returned by the String method of a Document.
+type StringForm int
+
+const (
+	// StringNormalized is the text after the normalization options ran.
+	StringNormalized StringForm = iota
+	// StringOriginal is the text as it was given.
+	StringOriginal
+)
+
+// WithStringForm selects the text returned by the String method of the Document.
+// Without it String returns the normalized text.
+func WithStringForm(form StringForm) Option {
+	return func(d *Document) {
+		d.stringForm = form
+	}
+}
+
+// WithOffsetTracking keeps where each part of the normalized text was written in the Original text, so
+// TokenSpans, MatchSpan and the offsets of the Signals, the Removals and the Segments refer to it. The
+// steps map the text they return to the text they were given as they write it, a custom Option that
+// changes the text is mapped as a whole to the text it was given. Without it the offsets are in the
+// text given to the step that found them and the Spans are in the normalized Text.
+func WithOffsetTracking() Option {
+	return func(d *Document) {
+		d.offsetTracking = true
+	}
+}
+
+// Original returns the text as it was given, before the normalization options ran.
+func (d Document) Original() string {
+	if d.original == "" {
+		// the Document was not built by a Normalizer
+		return d.Text
+	}
+	return d.original
+}
+
+// Normalized returns the text after the normalization options ran, the same as the Text field.
+func (d Document) Normalized() string {
+	return d.Text
+}
+
+// Span is the range of bytes [Start, End) of a text. A Span that could not be found has Start and End -1.
+type Span struct {
+	Start int
+	End   int
+}
+
+var noSpan = Span{Start: -1, End: -1}
+
+// TokenSpans returns where each one of the Tokens was written in the Original text, with WithOffsetTracking,
+// or in the normalized Text. The offsets of the text are carried through the normalization steps, so a token
+// replaced by something else (ex: WithReplacer) has the Span of the text it replaced. A token that is not in
+// the normalized text has no Span.
+func (d Document) TokenSpans() []Span {
+	positions := d.tokenPositions()
+	spans := make([]Span, len(positions))
+	for i, p := range positions {
+		spans[i] = d.originalSpan(p.span)
+	}
+	return spans
+}
+
+// MatchSpan returns where the tokens of the Match were written in the Original text, with
+// WithOffsetTracking, or in the normalized Text. A Match found in a Segment has the Span of the
+// Source of the Segment or, in a Segment read from the lines, the Span from the first letter
+// matched to the last one, in the text the offsets of the Segment refer to.
+func (d Document) MatchSpan(m Match) Span {
+	if m.Start < 0 || m.End <= m.Start {
+		return noSpan
+	}
+	if m.Segment != nil {
+		return d.segmentSpan(m)
+	}
+	positions := d.tokenPositions()
+	var pieces []Span
+	position := 0
+	for i, token := range d.Tokens {
+		startSpecial, word, endSpecial := splitSpecials(token)
+		sizes := make([]int, 0, len(startSpecial)+len(endSpecial)+1)
+		for range startSpecial {
+			sizes = append(sizes, 1)
+		}
+		sizes = append(sizes, utf8.RuneCountInString(word))
+		for range endSpecial {
+			sizes = append(sizes, 1)
+		}
+		from := 0
+		for _, size := range sizes {
+			if position >= m.Start && position < m.End {
+				pieces = append(pieces, d.originalSpan(positions[i].runes(token, from, from+size)))
+			}
+			from += size
+			position++
+		}
+		if position >= m.End {
+			break
+		}
+	}
+	return joinSpans(pieces)
+}
+
+// segmentSpan returns the Span of the Match found in its Segment.
+func (d Document) segmentSpan(m Match) Span {
+	for j := range d.segments {
+		if &d.segments[j] != m.Segment {
+			continue
+		}
+		if j < len(d.segmentSpans) && d.segmentSpans[j] != nil && m.End <= len(d.segmentSpans[j]) {
+			return joinSpans(d.segmentSpans[j][m.Start:m.End])
+		}
+		break
+	}
+	return Span{Start: m.Segment.Offset, End: m.Segment.Offset + len(m.Segment.Source)}
+}
+
+// joinSpans returns the Span from the start of the first Span found to the end of the last one.
+func joinSpans(spans []Span) Span {
+	joined := noSpan
+	for _, s := range spans {
+		if s.Start < 0 {
+			continue
+		}
+		if joined.Start < 0 {
+			joined.Start = s.Start
+		}
+		joined.End = s.End
+	}
+	return joined
+}
+
+// originalSpan returns the Span of the Original text the Span of the normalized text was written in.
+func (d Document) originalSpan(s Span) Span {
+	if s.Start < 0 || d.offsets == nil {
+		return s
+	}
+	return Span{Start: d.offsets.start(s.Start), End: d.offsets.end(s.End)}
+}
+
+// originalOffset returns the offset of the Original text the offset of the text being normalized
+// comes from.
+func (d Document) originalOffset(offset int) int {
+	if d.offsets == nil {
+		return offset
+	}
+	return d.offsets.start(offset)
+}
+
+// trackOffsets maps the text returned by the last step run, if it did not give its map, as a whole to
+// the text it was given.
+func (d *Document) trackOffsets() {
+	if d.offsets == nil || d.Text == d.offsetsText {
+		return
+	}
+	d.offsets = d.offsets.compose(wholeMap(d.offsetsText, d.Text))
+	d.offsetsText = d.Text
+}
+
+// setText replaces the text being normalized by the one returned by a step that knows its map: from
+// the offsets of the text returned to the offsets of the text given.
+func (d *Document) setText(text string, step offsetMap) {
+	if d.offsets != nil {
+		d.offsets = d.offsets.compose(step)
+		d.offsetsText = text
+	}
+	d.Text = text
+}
+
+// textBuilder builds the text returned by a step and, if the offsets are tracked, its map: from the
+// offsets of the text built to the offsets of the text given to the step.
+type textBuilder struct {
+	strings.Builder
+	track bool
+	m     offsetMap
+	// at is the offset of the text given after the last bytes written
+	at int
+}
+
+// newTextBuilder returns a textBuilder for a step of the Document, with room for size bytes.
+func (d Document) newTextBuilder(size int) *textBuilder {
+	b := &textBuilder{track: d.offsets != nil}
+	b.Grow(size)
+	return b
+}
+
+// write appends s, written in place of the bytes [from, to) of the text given.
+func (b *textBuilder) write(s string, from, to int) {
+	if b.track {
+		b.m = b.m.add(offsetPiece{start: b.Len(), end: b.Len() + len(s), origStart: from, origEnd: to})
+		b.at = to
+	}
+	b.WriteString(s)
+}
+
+// writeRune appends r, written in place of the bytes [from, to) of the text given.
+func (b *textBuilder) writeRune(r rune, from, to int) {
+	if b.track {
+		b.m = b.m.add(offsetPiece{start: b.Len(), end: b.Len() + utf8.RuneLen(r), origStart: from, origEnd: to})
+		b.at = to
+	}
+	b.WriteRune(r)
+}
+
+// insert appends s, added by the step after the last bytes written.
+func (b *textBuilder) insert(s string) {
+	b.write(s, b.at, b.at)
+}
+
+// setText replaces the text being normalized by the one built.
+func (b *textBuilder) setText(d *Document) {
+	d.setText(b.String(), b.m)
+}
+
+// applyLocal replaces the text being normalized by the one returned by f, a function changing each
+// word of the text on its own (ex: changing the case or the accents). When the offsets are tracked the
+// words, and the runes of the words f changes the length of, are mapped to the ones f returns for them.
+func (d *Document) applyLocal(f func(string) (string, error)) error {
+	text, err := f(d.Text)
+	if err != nil {
+		return err
+	}
+	if d.offsets == nil {
+		d.Text = text
+		return nil
+	}
+	d.setText(text, mapLocal(d.Text, text, f))
+	return nil
+}
+
+// mapLocal returns the map of the text returned by f applied to the text given, from f applied to
+// each one of its words. The text is mapped as a whole if the words do not give the text returned.
+func mapLocal(given, returned string, f func(string) (string, error)) offsetMap {
+	var m offsetMap
+	n := 0
+	for i := 0; i < len(given); {
+		j := wordEnd(given, i)
+		word, err := f(given[i:j])
+		if err != nil || !strings.HasPrefix(returned[n:], word) {
+			return wholeMap(given, returned)
+		}
+		if len(word) == j-i {
+			m = m.add(offsetPiece{start: n, end: n + len(word), origStart: i, origEnd: j})
+		} else {
+			m = mapRunes(m, given, word, n, i, j, f)
+		}
+		n += len(word)
+		i = j
+	}
+	if n != len(returned) {
+		return wholeMap(given, returned)
+	}
+	return m
+}
+
+// mapRunes adds to m the map of the word returned by f for given[from:to], written at the offset n,
+// from f applied to each rune of the word with its combining marks. The word is mapped as a whole if
+// the runes do not give it.
+func mapRunes(m offsetMap, given, word string, n, from, to int, f func(string) (string, error)) offsetMap {
+	pieces := make(offsetMap, 0, to-from)
+	at := n
+	for i := from; i < to; {
+		j := i + norm.NFC.NextBoundaryInString(given[i:to], true)
+		r, err := f(given[i:j])
+		if err != nil || !strings.HasPrefix(word[at-n:], r) {
+			return m.add(offsetPiece{start: n, end: n + len(word), origStart: from, origEnd: to})
+		}
+		pieces = append(pieces, offsetPiece{start: at, end: at + len(r), origStart: i, origEnd: j})
+		at += len(r)
+		i = j
+	}
+	if at-n != len(word) {
+		return m.add(offsetPiece{start: n, end: n + len(word), origStart: from, origEnd: to})
+	}
+	for _, p := range pieces {
+		m = m.add(p)
+	}
+	return m
+}
+
+// wordEnd returns the offset after the run of white spaces or of other runes starting at the offset.
+func wordEnd(text string, offset int) int {
+	r, size := utf8.DecodeRuneInString(text[offset:])
+	space := unicode.IsSpace(r)
+	for offset += size; offset < len(text); offset += size {
+		r, size = utf8.DecodeRuneInString(text[offset:])
+		if unicode.IsSpace(r) != space {
+			break
+		}
+	}
+	return offset
+}
+
+// wholeMap returns the map of a step mapping the text returned as a whole to the text given.
+func wholeMap(given, returned string) offsetMap {
+	return offsetMap{}.add(offsetPiece{start: 0, end: len(returned), origStart: 0, origEnd: len(given)})
+}
+
+// tokenPosition is where a token was found in the normalized text. The offsets of its runes are only
+// kept when they are not sequential in the text (ex: the spaces removed by NewJoinedTokenizer).
+type tokenPosition struct {
+	span    Span
+	offsets []int
+}
+
+// runes returns the Span of the runes [from, to) of the token.
+func (p tokenPosition) runes(token string, from, to int) Span {
+	if p.span.Start < 0 || from >= to {
+		return noSpan
+	}
+	if p.offsets != nil {
+		return Span{Start: p.offsets[from], End: p.offsets[to-1] + utf8.RuneLen([]rune(token)[to-1])}
+	}
+	s := Span{Start: -1, End: p.span.End}
+	n := 0
+	for i := range token {
+		if n == from {
+			s.Start = p.span.Start + i
+		}
+		if n == to {
+			s.End = p.span.Start + i
+			break
+		}
+		n++
+	}
+	return s
+}
+
+// tokenPositions returns the positions of the Tokens in the normalized text, the ones cached when the
+// Document was created if its Tokens did not change.
+func (d Document) tokenPositions() []tokenPosition {
+	if d.cacheValid() && d.positions != nil {
+		return d.positions
+	}
+	return locateTokens(d.Text, d.Tokens)
+}
+
+// locateTokens looks for the tokens in the text in order, each one after the previous one found.
+// The tokens that are not written as they are may have white spaces between their runes.
+func locateTokens(text string, tokens []string) []tokenPosition {
+	positions := make([]tokenPosition, len(tokens))
+	cursor := 0
+	for i, token := range tokens {
+		positions[i] = tokenPosition{span: noSpan}
+		if token == "" {
+			continue
+		}
+		if at := strings.Index(text[cursor:], token); at >= 0 {
+			start := cursor + at
+			positions[i].span = Span{Start: start, End: start + len(token)}
+			cursor = start + len(token)
+			continue
+		}
+		if offsets, ok := locateSpaced(text, token, cursor); ok {
+			_, size := utf8.DecodeLastRuneInString(token)
+			end := offsets[len(offsets)-1] + size
+			positions[i] = tokenPosition{span: Span{Start: offsets[0], End: end}, offsets: offsets}
+			cursor = end
+		}
+	}
+	return positions
+}
+
+// locateSpaced looks for the runes of the token in the text from the cursor on, allowing white
+// spaces between them, and returns their offsets.
+func locateSpaced(text, token string, cursor int) ([]int, bool) {
+	first, _ := utf8.DecodeRuneInString(token)
+	for {
+		at := strings.IndexRune(text[cursor:], first)
+		if at < 0 {
+			return nil, false
+		}
+		start := cursor + at
+		offsets := make([]int, 0, len(token))
+		i := start
+		matched := true
+		for _, r := range token {
+			for i < len(text) {
+				c, size := utf8.DecodeRuneInString(text[i:])
+				if !unicode.IsSpace(c) || len(offsets) == 0 {
+					break
+				}
+				i += size
+			}
+			c, size := utf8.DecodeRuneInString(text[i:])
+			if i == len(text) || c != r {
+				matched = false
+				break
+			}
+			offsets = append(offsets, i)
+			i += size
+		}
+		if matched {
+			return offsets, true
+		}
+		cursor = start + utf8.RuneLen(first)
+	}
+}
+
+// offsetPiece maps the bytes [start, end) of a text to the bytes [origStart, origEnd) of the Original one.
+type offsetPiece struct {
+	start, end         int
+	origStart, origEnd int
+}
+
+// exact reports whether the piece maps each byte to the one at the same position.
+func (p offsetPiece) exact() bool {
+	return p.end-p.start == p.origEnd-p.origStart
+}
+
+// offsetMap maps the offsets of the text being normalized to the offsets of the Original text. Its
+// pieces are sorted and cover the text. The pieces of the same length map each byte to the byte at
+// the same position, the other ones only map their bounds (ex: "xxx" replacing "cocaína").
+type offsetMap []offsetPiece
+
+// newOffsetMap returns the map of a text not normalized yet.
+func newOffsetMap(text string) offsetMap {
+	if text == "" {
+		return offsetMap{}
+	}
+	return offsetMap{{start: 0, end: len(text), origStart: 0, origEnd: len(text)}}
+}
+
+// add appends the piece, joining it to the last one when both map byte by byte.
+func (m offsetMap) add(p offsetPiece) offsetMap {
+	if p.start == p.end {
+		return m
+	}
+	if n := len(m) - 1; n >= 0 && m[n].exact() && p.exact() && m[n].end == p.start && m[n].origEnd == p.origStart {
+		m[n].end, m[n].origEnd = p.end, p.origEnd
+		return m
+	}
+	return append(m, p)
+}
+
+// start returns the offset of the Original text where the byte at the offset comes from.
+func (m offsetMap) start(offset int) int {
+	i := sort.Search(len(m), func(i int) bool {
+		return m[i].end > offset
+	})
+	if i == len(m) {
+		if i == 0 {
+			return 0
+		}
+		return m[i-1].origEnd
+	}
+	p := m[i]
+	if p.exact() {
+		return p.origStart + offset - p.start
+	}
+	return p.origStart
+}
+
+// end returns the offset of the Original text after the byte before the offset.
+func (m offsetMap) end(offset int) int {
+	i := sort.Search(len(m), func(i int) bool {
+		return m[i].end >= offset
+	})
+	if i == len(m) || offset == 0 {
+		return m.start(offset)
+	}
+	p := m[i]
+	if p.exact() {
+		return p.origStart + offset - p.start
+	}
+	return p.origEnd
+}
+
+// compose returns the map of the text normalized by a step, given the map of the step: from the
+// offsets of the text it returned to the offsets of the text it was given.
+func (m offsetMap) compose(step offsetMap) offsetMap {
+	composed := make(offsetMap, 0, len(m)+len(step))
+	for _, q := range step {
+		if !q.exact() {
+			composed = composed.add(offsetPiece{
+				start: q.start, end: q.end, origStart: m.start(q.origStart), origEnd: m.end(q.origEnd),
+			})
+			continue
+		}
+		i := sort.Search(len(m), func(i int) bool {
+			return m[i].end > q.origStart
+		})
+		for ; i < len(m) && m[i].start < q.origEnd; i++ {
+			p := m[i]
+			from, to := maxInt(p.start, q.origStart), minInt(p.end, q.origEnd)
+			piece := offsetPiece{start: q.start + from - q.origStart, end: q.start + to - q.origStart}
+			if p.exact() {
+				piece.origStart, piece.origEnd = p.origStart+from-p.start, p.origStart+to-p.start
+			} else {
+				piece.origStart, piece.origEnd = m.start(from), m.end(to)
+			}
+			composed = composed.add(piece)
+		}
+	}
+	return composed
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,237 @@
+package gomtch
+
+import (
+	"golang.org/x/text/language"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDocument_String(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"normalized", []Option{WithSetLower(), WithTransform(NewASCII())}, "compre cocaina"},
+		{"normalizedForm", []Option{WithSetLower(), WithStringForm(StringNormalized)}, "compre cocaína"},
+		{"original", []Option{WithSetLower(), WithTransform(NewASCII()), WithStringForm(StringOriginal)},
+			"Compre COCAÍNA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument("Compre COCAÍNA", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := d.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+			if got := d.Original(); got != "Compre COCAÍNA" {
+				t.Errorf("Original() = %v", got)
+			}
+			if got := d.Normalized(); got != d.Text {
+				t.Errorf("Normalized() = %v, want %v", got, d.Text)
+			}
+		})
+	}
+}
+
+func TestDocument_TokenSpans(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts []Option
+		want []string
+	}{
+		{"default", "compre  cocaína aqui", nil, []string{"compre", "cocaína", "aqui"}},
+		{"lowerAndASCII", "Compre COCAÍNA", []Option{WithSetLower(), WithTransform(NewASCII())},
+			[]string{"Compre", "COCAÍNA"}},
+		{"html", "<p>compre <b>coca</b>ína</p>", []Option{WithHMTLParsing()},
+			[]string{"compre", "coca</b>ína"}},
+		{"latinFold", "Straße œuvre", []Option{WithTransform(NewLatinFold())}, []string{"Straße", "œuvre"}},
+		{"compatibility", "🅲🅾🅲🅰 ｃｏｃａ", []Option{WithCompatibilityFolding(), WithCaseFolding(language.Und)},
+			[]string{"🅲🅾🅲🅰", "ｃｏｃａ"}},
+		{"invisible", "co​ca­ína x", []Option{WithInvisibleRemoval(InvisibleAll)},
+			[]string{"co​ca­ína", "x"}},
+		{"sequential", "reaaal cocaaaína", []Option{WithSequentialEqualCharsRemoval()},
+			[]string{"reaaal", "cocaaaína"}},
+		{"skeleton", "сосаіnа", []Option{WithTransform(NewSkeleton())}, []string{"сосаіnа"}},
+		{"joined", "coca ína", []Option{WithTokenizer(NewJoinedTokenizer())}, []string{"coca ína"}},
+		{"replaced", "compre cocaína aqui", []Option{WithReplacer(regexp.MustCompile(`cocaína`), "xxx")},
+			[]string{"compre", "cocaína", "aqui"}},
+		{"repeated", "coca e coca", nil, []string{"coca", "e", "coca"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, append(tt.opts, WithOffsetTracking())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range d.TokenSpans() {
+				if s.Start < 0 {
+					got = append(got, "")
+					continue
+				}
+				got = append(got, tt.text[s.Start:s.End])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TokenSpans() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_MatchSpan(t *testing.T) {
+	text := "Compre (COCAÍNA) e MACONHA aqui!"
+	d, err := NewDocument(text, WithSetLower(), WithTransform(NewASCII()), WithOffsetTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+	}{
+		{"word", "cocaina", "COCAÍNA"},
+		{"sequence", "maconha aqui", "MACONHA aqui"},
+		{"withSpecials", "cocaina ) e", "COCAÍNA) e"},
+		{"endSpecial", "aqui !", "aqui!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := NewDocument(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches := d.Find(pattern)
+			if len(matches) != 1 {
+				t.Fatalf("Find() = %v", matches)
+			}
+			s := d.MatchSpan(matches[0])
+			if s.Start < 0 || text[s.Start:s.End] != tt.want {
+				t.Errorf("MatchSpan() = %v, want %q", s, tt.want)
+			}
+		})
+	}
+	if got := d.MatchSpan(Match{Start: -1, End: -1}); got != noSpan {
+		t.Errorf("MatchSpan() without position = %v", got)
+	}
+}
+
+func TestDocument_MatchSpanSteps(t *testing.T) {
+	script := "<script>" + strings.Repeat("var coca = 1;", 10) + "</script>"
+	tests := []struct {
+		name    string
+		text    string
+		opts    []Option
+		pattern string
+		want    string
+	}{
+		{"longRemoval", "<html><head>" + script + "</head><body><p>compre <b>coca</b>ína</p></body></html>",
+			[]Option{WithHTMLExtraction(HTMLExtraction{})}, "cocaína", "coca</b>ína"},
+		{"entities", "compre &#99;&#111;caína hoje", []Option{WithEntityDecoding(), WithTransform(NewASCII())},
+			"cocaina", "&#99;&#111;caína"},
+		{"ligature", "ﬁnal da coca", []Option{WithCompatibilityFolding()}, "final", "ﬁnal"},
+		{"repeated", "Coca coca COCAÍNA", []Option{WithSetLower()}, "cocaína", "COCAÍNA"},
+		{"replaced", "compre pó aqui", []Option{WithReplacer(regexp.MustCompile(`pó`), "cocaína")},
+			"cocaína aqui", "pó aqui"},
+		{"invisible", "<p>compre <b>co\u200bca</b>\u00adína</p>",
+			[]Option{WithHMTLParsing(), WithInvisibleRemoval(InvisibleAll)}, "cocaína", "co\u200bca</b>\u00adína"},
+		{"joined", "co ca í na", []Option{WithTokenizer(NewJoinedTokenizer())}, "cocaína", "co ca í na"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, append(tt.opts, WithOffsetTracking())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pattern, err := NewDocument(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches := d.Find(pattern)
+			if len(matches) != 1 {
+				t.Fatalf("Find() = %v in %q", matches, d.Text)
+			}
+			s := d.MatchSpan(matches[0])
+			if s.Start < 0 || tt.text[s.Start:s.End] != tt.want {
+				t.Errorf("MatchSpan() = %v, want %q", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_MatchSpanSegments(t *testing.T) {
+	lines := "<p>oi</p>\n" + acrosticText
+	tests := []struct {
+		name    string
+		text    string
+		opts    []Option
+		pattern string
+		want    string
+	}{
+		{"base64", "&lt;compre&gt; Q09DQcONTkE=",
+			[]Option{WithEntityDecoding(), WithSegmentDecoding(SegmentBase64), WithSetLower()}, "cocaína", "Q09DQcONTkE="},
+		{"rot13", "compre pbpnvan", []Option{WithSegmentDecoding(SegmentROT13)}, "cocaina", "compre pbpnvan"},
+		{"lines", lines, []Option{WithHMTLParsing(), WithLineReading(SegmentAcrostic), WithSetLower()}, "corpora",
+			lines[strings.Index(lines, "Compre") : strings.Index(lines, "Acesse")+1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDocument(tt.text, append(tt.opts, WithOffsetTracking())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pattern, err := NewDocument(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches := d.Find(pattern)
+			if len(matches) != 1 || matches[0].Segment == nil {
+				t.Fatalf("Find() = %+v", matches)
+			}
+			s := d.MatchSpan(matches[0])
+			if s.Start < 0 || tt.text[s.Start:s.End] != tt.want {
+				t.Errorf("MatchSpan() = %v, want %q", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_TokenSpansUntracked(t *testing.T) {
+	d, err := NewDocument("Compre &#99;OCAÍNA", WithEntityDecoding(), WithSetLower())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Span{{Start: 0, End: 6}, {Start: 7, End: 15}}
+	if got := d.TokenSpans(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TokenSpans() = %v, want %v", got, want)
+	}
+	if d.offsets != nil {
+		t.Errorf("offsets = %v, want none without WithOffsetTracking", d.offsets)
+	}
+}
+
+func Test_offsetMap(t *testing.T) {
+	given, returned := "Compre COCAÍNA já", "compre cocaina ja"
+	d := Document{Text: given, offsets: newOffsetMap(given), offsetsText: given}
+	for _, f := range []func(string) (string, error){toLower, NewASCII().Transform} {
+		if err := d.applyLocal(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d.Text != returned {
+		t.Fatalf("Text = %q, want %q", d.Text, returned)
+	}
+	m := d.offsets
+	for _, word := range []struct{ returned, given string }{{"compre", "Compre"}, {"cocaina", "COCAÍNA"}, {"ja", "já"}} {
+		start := strings.Index(returned, word.returned)
+		s := Span{Start: m.start(start), End: m.end(start + len(word.returned))}
+		if got := given[s.Start:s.End]; got != word.given {
+			t.Errorf("span of %q = %q, want %q", word.returned, got, word.given)
+		}
+	}
+}
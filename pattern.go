@@ -0,0 +1,465 @@
+package gomtch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PatternToken is a token of a Pattern with its own matching settings.
+// Each alternative may hold character classes: "[o0]" matches only one of the runes between the
+// brackets and "*" matches any rune (ex: "c[o0]rp*ra"). A "*" between the brackets lets the class
+// be masked as well (ex: "[o0*]"). A backslash escapes the next rune.
+type PatternToken struct {
+	// Alternatives are the texts the token matches, one of them is enough.
+	Alternatives []string
+	// Optional tokens may be missing from the text.
+	Optional bool
+	// Exact tokens can not have runes masked.
+	Exact bool
+	// Score is the minimum match score of the token. If it is zero the options of the Pattern apply.
+	Score int
+	// CaseSensitive tokens match in the same case even if the Pattern was created WithCaseInsensitiveMatching.
+	CaseSensitive bool
+}
+
+// Pattern is a sequence of tokens, each one with its own matching settings, looked for in the
+// texts as a Document is (ex: by Scan or Find). The words split in the text (ex: "c o c a í n a") are
+// compared to the alternatives of the tokens joined, with the options of the Pattern, as long as the
+// tokens have no settings of their own and the alternatives hold no character classes.
+type Pattern struct {
+	source string
+	base   *Document
+	tokens []patternToken
+	// joined are the Documents of the alternatives of the tokens joined
+	joined []*Document
+}
+
+// maxPatternJoined is the number of combinations of the alternatives of the tokens compared
+// to the words split in the text.
+const maxPatternJoined = 16
+
+type patternToken struct {
+	alternatives []patternAlternative
+	optional     bool
+	// own is set if the token has settings of its own (ex: Exact)
+	own bool
+}
+
+// patternAlternative is an alternative of a token normalized as a Document. The classes are
+// placed by the position of their runes in the mapped tokens of the Document.
+type patternAlternative struct {
+	doc     *Document
+	refs    [][]rune
+	classes map[int]patternClass
+}
+
+// patternClass is a character class of an alternative, any rune if runes is nil.
+type patternClass struct {
+	runes []rune
+}
+
+// matches reports whether r is a member of the class, compared by d.
+func (c patternClass) matches(d *Document, r rune) bool {
+	if c.runes == nil {
+		return true
+	}
+	for _, v := range c.runes {
+		if d.equalRune(v, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// classPlaceholder stands for the "*" of an alternative while it is normalized.
+const classPlaceholder = 'a'
+
+// NewPattern normalizes each alternative of the tokens with the options, as NewDocument does, and
+// returns the Pattern matching them in sequence. The normalization options must not change the length
+// of the alternatives holding character classes.
+func NewPattern(tokens []PatternToken, opts ...Option) (*Pattern, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	base, err := NewDocument("", opts...)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pattern{base: base}
+	var sources []string
+	for _, t := range tokens {
+		if len(t.Alternatives) == 0 {
+			return nil, fmt.Errorf("pattern token without alternatives")
+		}
+		tokenOpts := append([]Option{}, opts...)
+		switch {
+		case t.Exact:
+			tokenOpts = append(tokenOpts, WithFuzziness(FuzzinessExact))
+		case t.Score != 0:
+			tokenOpts = append(tokenOpts, WithMinimumMatchScore(t.Score))
+		}
+		if t.CaseSensitive {
+			tokenOpts = append(tokenOpts, func(d *Document) {
+				d.caseInsensitive = false
+			})
+		}
+		token := patternToken{optional: t.Optional, own: t.Exact || t.Score != 0 || t.CaseSensitive}
+		for _, a := range t.Alternatives {
+			alternative, err := newPatternAlternative(a, tokenOpts, opts)
+			if err != nil {
+				return nil, err
+			}
+			token.alternatives = append(token.alternatives, alternative)
+		}
+		p.tokens = append(p.tokens, token)
+		sources = append(sources, strings.Join(t.Alternatives, "|"))
+	}
+	p.source = strings.Join(sources, " ")
+	p.joinAlternatives()
+	return p, nil
+}
+
+// joinAlternatives sets the Documents of the combinations of the alternatives of the tokens, up to
+// maxPatternJoined, skipping the tokens with settings of their own and the alternatives holding
+// character classes.
+func (p *Pattern) joinAlternatives() {
+	combinations := [][]string{nil}
+	for _, t := range p.tokens {
+		var next [][]string
+		for _, a := range t.alternatives {
+			if t.own || a.classes != nil {
+				continue
+			}
+			for _, c := range combinations {
+				next = append(next, append(append([]string(nil), c...), a.doc.Tokens...))
+			}
+		}
+		if t.optional {
+			next = append(next, combinations...)
+		}
+		if len(next) > maxPatternJoined {
+			next = next[:maxPatternJoined]
+		}
+		combinations = next
+	}
+	for _, c := range combinations {
+		if len(c) == 0 {
+			continue
+		}
+		d := *p.base
+		d.Tokens = c
+		d.cache()
+		p.joined = append(p.joined, &d)
+	}
+}
+
+func newPatternAlternative(text string, tokenOpts, opts []Option) (patternAlternative, error) {
+	var b strings.Builder
+	classes := map[int]patternClass{}
+	runes := []rune(text)
+	position := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			r = runes[i]
+		case r == '*':
+			classes[position] = patternClass{}
+			r = classPlaceholder
+		case r == '[':
+			end := i + 1
+			for ; end < len(runes) && runes[end] != ']'; end++ {
+			}
+			if end == len(runes) || end == i+1 {
+				return patternAlternative{}, fmt.Errorf("invalid character class in %q", text)
+			}
+			class, err := newPatternClass(runes[i+1:end], opts)
+			if err != nil {
+				return patternAlternative{}, err
+			}
+			classes[position] = class
+			r = runes[i+1]
+			i = end
+		}
+		b.WriteRune(r)
+		if !unicode.IsSpace(r) {
+			position++
+		}
+	}
+	doc, err := NewDocument(b.String(), tokenOpts...)
+	if err != nil {
+		return patternAlternative{}, err
+	}
+	a := patternAlternative{doc: doc}
+	mapped := doc.Mapped()
+	size := 0
+	for _, id := range mapped.Ids {
+		a.refs = append(a.refs, mapped.GetRunesByID(id))
+		size += len(mapped.GetRunesByID(id))
+	}
+	if len(a.refs) == 0 {
+		return patternAlternative{}, fmt.Errorf("empty pattern alternative %q", text)
+	}
+	if len(classes) != 0 {
+		if size != position {
+			return patternAlternative{}, fmt.Errorf("the normalization changed the length of %q, "+
+				"its character classes can not be placed", text)
+		}
+		a.classes = classes
+	}
+	return a, nil
+}
+
+// newPatternClass returns the class of the runes, with the runes as they are and normalized.
+func newPatternClass(runes []rune, opts []Option) (patternClass, error) {
+	var class patternClass
+	for _, r := range runes {
+		class.runes = append(class.runes, r)
+		d, err := NewDocument(string(r), opts...)
+		if err != nil {
+			return patternClass{}, err
+		}
+		if normalized := []rune(d.Text); len(normalized) == 1 && normalized[0] != r {
+			class.runes = append(class.runes, normalized[0])
+		}
+	}
+	return class, nil
+}
+
+// ParsePattern parses the pattern and returns it as NewPattern does. The tokens are separated by white
+// spaces and alternatives by "|" (ex: "buy|sell"). A token starting with "=" is Exact and with "^"
+// CaseSensitive, a token ending with "~N" has the minimum match score N and with "?" is Optional
+// (ex: "text quite hard~60 =to match?"). The tokens may hold character classes (ex: "c[o0]rp*ra").
+func ParsePattern(pattern string, opts ...Option) (*Pattern, error) {
+	var tokens []PatternToken
+	for _, field := range strings.Fields(pattern) {
+		t, err := parsePatternToken(field)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	p, err := NewPattern(tokens, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.source = pattern
+	return p, nil
+}
+
+func parsePatternToken(field string) (PatternToken, error) {
+	var t PatternToken
+	for {
+		if strings.HasPrefix(field, "=") {
+			t.Exact = true
+		} else if strings.HasPrefix(field, "^") {
+			t.CaseSensitive = true
+		} else {
+			break
+		}
+		field = field[1:]
+	}
+	if hasUnescapedSuffix(field, "?") {
+		t.Optional = true
+		field = field[:len(field)-1]
+	}
+	if i := strings.LastIndex(field, "~"); i > 0 && field[i-1] != '\\' {
+		score, err := strconv.Atoi(field[i+1:])
+		if err != nil || score < 0 || score > 100 {
+			return PatternToken{}, fmt.Errorf("invalid score in pattern token %q", field)
+		}
+		t.Score = score
+		field = field[:i]
+	}
+	t.Alternatives = splitAlternatives(field)
+	for _, a := range t.Alternatives {
+		if a == "" {
+			return PatternToken{}, fmt.Errorf("empty alternative in pattern token %q", field)
+		}
+	}
+	return t, nil
+}
+
+// hasUnescapedSuffix reports whether s ends with the suffix not preceded by a backslash.
+func hasUnescapedSuffix(s, suffix string) bool {
+	if !strings.HasSuffix(s, suffix) {
+		return false
+	}
+	return !strings.HasSuffix(s[:len(s)-len(suffix)], "\\")
+}
+
+// splitAlternatives splits the token on the "|" that are not escaped nor in a character class.
+func splitAlternatives(token string) []string {
+	var alternatives []string
+	var inClass bool
+	start := 0
+	for i := 0; i < len(token); i++ {
+		switch token[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '|':
+			if !inClass {
+				alternatives = append(alternatives, token[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(alternatives, token[start:])
+}
+
+// String returns the pattern.
+func (p Pattern) String() string {
+	return p.source
+}
+
+// CompareRune compares the runes as the Documents created with the options of the Pattern do.
+func (p Pattern) CompareRune(a, b rune) bool {
+	return p.base.CompareRune(a, b)
+}
+
+// IsEqual compares the runes as the Documents created with the options of the Pattern do.
+func (p Pattern) IsEqual(a, b []rune) bool {
+	return p.base.IsEqual(a, b)
+}
+
+func (p Pattern) Compare(tokens Tokens) (bool, []rune) {
+	m, ok := p.Locate(tokens)
+	return ok, m.Sequence
+}
+
+// Locate looks for the tokens of the Pattern in sequence in the tokens, each one compared with its own
+// settings. The optional tokens are skipped when they are not found. If they are not found the words
+// split in the tokens are compared to the alternatives joined.
+func (p Pattern) Locate(tokens Tokens) (Match, bool) {
+	var chosen []patternChoice
+	for start := range tokens.Ids {
+		end, ok := p.matchFrom(0, start, tokens, &chosen)
+		if !ok || end == start {
+			continue
+		}
+		positions := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			positions = append(positions, i)
+		}
+		m := Match{
+			Sequence: joinTokens(tokens, positions, nil),
+			Start:    start,
+			End:      end,
+		}
+		var s matchScore
+		for _, c := range chosen {
+			refs, _ := c.alternative.refsAt(c.position, tokens)
+			for i, ref := range refs {
+				c.alternative.doc.describeTokens(&m, &s, c.index, [][]rune{ref}, tokens, []int{c.position + i}, nil, nil)
+			}
+		}
+		m.Score = s.value()
+		return m, true
+	}
+	return p.locateJoined(tokens)
+}
+
+// locateJoined looks for the alternatives of the tokens joined split in many sequential tokens.
+func (p Pattern) locateJoined(tokens Tokens) (Match, bool) {
+	for _, d := range p.joined {
+		found, special, start, end := d.specialCheck(d.joined, tokens)
+		if !found {
+			continue
+		}
+		m := Match{
+			Sequence: special,
+			Start:    start,
+			End:      end,
+		}
+		d.describe(&m, nil, tokens, nil, withoutSpaces(special), d.joined)
+		return m, true
+	}
+	return Match{}, false
+}
+
+// patternChoice is an alternative of the token at the index matched to the tokens at the position.
+type patternChoice struct {
+	alternative *patternAlternative
+	index       int
+	position    int
+}
+
+// matchFrom matches the tokens of the Pattern from the index on to the tokens starting at the
+// position, appending the alternatives matched to chosen. It returns the position after the last
+// token matched.
+func (p Pattern) matchFrom(index, position int, tokens Tokens, chosen *[]patternChoice) (int, bool) {
+	if index == len(p.tokens) {
+		return position, true
+	}
+	t := p.tokens[index]
+	for i := range t.alternatives {
+		a := &t.alternatives[i]
+		if n, ok := a.matchAt(index, position, tokens); ok {
+			*chosen = append(*chosen, patternChoice{alternative: a, index: index, position: position})
+			if end, ok := p.matchFrom(index+1, position+n, tokens, chosen); ok {
+				return end, true
+			}
+			*chosen = (*chosen)[:len(*chosen)-1]
+		}
+	}
+	if t.optional {
+		return p.matchFrom(index+1, position, tokens, chosen)
+	}
+	return 0, false
+}
+
+// matchAt compares the alternative to the tokens starting at the position and returns the number
+// of tokens it matched.
+func (a patternAlternative) matchAt(index, position int, tokens Tokens) (int, bool) {
+	if position+len(a.refs) > len(tokens.Ids) {
+		return 0, false
+	}
+	refs, ok := a.refsAt(position, tokens)
+	if !ok {
+		return 0, false
+	}
+	for i, ref := range refs {
+		if !a.doc.isEqual(tokens.GetRunesByID(tokens.Ids[position+i]), ref, index, CheckToken) {
+			return 0, false
+		}
+	}
+	return len(refs), true
+}
+
+// refsAt returns the refs of the alternative compared to the tokens starting at the position: the
+// runes of the text in a character class are taken as the ones of the pattern. It reports false if
+// a rune of the text is not a member of its class.
+func (a patternAlternative) refsAt(position int, tokens Tokens) ([][]rune, bool) {
+	if a.classes == nil {
+		return a.refs, true
+	}
+	refs := make([][]rune, len(a.refs))
+	offset := 0
+	for i, ref := range a.refs {
+		text := tokens.GetRunesByID(tokens.Ids[position+i])
+		refs[i] = ref
+		copied := false
+		for j := range ref {
+			c, ok := a.classes[offset+j]
+			if !ok {
+				continue
+			}
+			if len(text) != len(ref) || !c.matches(a.doc, text[j]) {
+				return nil, false
+			}
+			if !copied {
+				refs[i], copied = append([]rune(nil), ref...), true
+			}
+			refs[i][j] = text[j]
+		}
+		offset += len(ref)
+	}
+	return refs, true
+}
@@ -0,0 +1,98 @@
+package gomtch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		opts    []Option
+		text    string
+		want    bool
+		match   string
+	}{
+		{"fuzzyToken", "text quite hard~60 =to match", nil, "a text quite h**d to match", true,
+			"text quite h**d to match"},
+		{"exactToken", "text quite hard~60 =to match", nil, "a text quite hard t* match", false, ""},
+		{"defaultScore", "text quite hard~60 =to match", nil, "a text q***e hard to match", false, ""},
+		{"optionalMissing", "buy cheap? cocaína", nil, "buy cocaína now", true, "buy cocaína"},
+		{"optionalPresent", "buy cheap? cocaína", nil, "buy cheap cocaína", true, "buy cheap cocaína"},
+		{"alternatives", "buy|sell cocaína", nil, "sell cocaína", true, "sell cocaína"},
+		{"alternativesMissing", "buy|sell cocaína", nil, "want cocaína", false, ""},
+		{"class", "c[o0]rp*ra", nil, "c0rpura", true, "c0rpura"},
+		{"classNotMember", "c[o0]rp*ra", []Option{WithFuzziness(FuzzinessExact)}, "c1rpora", false, ""},
+		{"classMasked", "c[o0]rp*ra", nil, "c.rpura", false, ""},
+		{"classMaskedScore", "c[o0]rp*ra", []Option{WithMinimumMatchScore(80)}, "c.rpura", false, ""},
+		{"classMaskedMember", "c[o0]rp*ra", []Option{WithMinimumMatchScore(80)}, "c0r*ura", true, "c0r*ura"},
+		{"classWildcard", "c[o0*]rp*ra", nil, "c*rpura", true, "c*rpura"},
+		{"classCaseInsensitive", "c[o0]rpora", []Option{WithCaseInsensitiveMatching()}, "cOrpora", true, "cOrpora"},
+		{"joined", "cocaína", nil, "c o c a í n a", true, "c o c a í n a"},
+		{"joinedAlternatives", "buy|sell cocaína", nil, "sell co ca í na", true, "sell co ca í na"},
+		{"joinedOptional", "buy cheap? cocaína", nil, "buy c o c a í n a", true, "buy c o c a í n a"},
+		{"joinedClass", "c[o0]caína", nil, "c o c a í n a", false, ""},
+		{"classNormalized", "c[OÓ]caína", []Option{WithSetLower(), WithTransform(NewASCII())}, "COCAINA", true,
+			"cocaina"},
+		{"caseInsensitive", "cocaína", []Option{WithCaseInsensitiveMatching()}, "COCAÍNA", true, "COCAÍNA"},
+		{"caseSensitive", "^cocaína", []Option{WithCaseInsensitiveMatching()}, "COCAÍNA", false, ""},
+		{"strategy", "buy corpora", []Option{WithMinimumMatchScore(80), WithVowelDropping()}, "buy crpr", true,
+			"buy crpr"},
+		{"exactStrategy", "buy =corpora", []Option{WithMinimumMatchScore(80), WithVowelDropping()}, "buy crpr", false,
+			""},
+		{"escaped", `100\?`, nil, "100?", true, "100 ?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePattern(tt.pattern, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, err := NewDocument(tt.text, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, sequence := p.Compare(text.Mapped())
+			if got != tt.want || string(sequence) != tt.match {
+				t.Errorf("Compare() = %v %q, want %v %q", got, string(sequence), tt.want, tt.match)
+			}
+		})
+	}
+}
+
+func TestParsePattern_Errors(t *testing.T) {
+	for _, pattern := range []string{"", "co[ca", "buy|", "hard~x", "hard~101", "c[]ca"} {
+		if _, err := ParsePattern(pattern); err == nil {
+			t.Errorf("ParsePattern(%q) error = nil", pattern)
+		}
+	}
+	if _, err := ParsePattern("c[o0]caa*na", WithSequentialEqualCharsRemoval()); err == nil {
+		t.Error("ParsePattern() error = nil, want the classes not placed")
+	}
+}
+
+func TestNewPattern(t *testing.T) {
+	p, err := NewPattern([]PatternToken{
+		{Alternatives: []string{"buy", "purchase now"}},
+		{Alternatives: []string{"coca"}, Optional: true},
+		{Alternatives: []string{"c[o0]ca*na"}, Exact: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "buy|purchase now coca c[o0]ca*na" {
+		t.Errorf("String() = %q", p.String())
+	}
+	d, err := NewDocument("please purchase now c0caína")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Match{{Index: 0, Sequence: []rune("purchase now c0caína"), Start: 1, End: 4, Score: 100}}
+	if got := d.Find(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+	if got := d.Scan(p); !reflect.DeepEqual(got, Matches{0: []rune("purchase now c0caína")}) {
+		t.Errorf("Scan() = %v", got)
+	}
+}
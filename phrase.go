@@ -0,0 +1,82 @@
+package gomtch
+
+// PhraseScore configures WithPhraseScore.
+type PhraseScore struct {
+	// Minimum is the score the phrase must reach as a whole, from 0 to 100.
+	Minimum int
+	// Floor is the score each token must reach on its own, from 0 to 100.
+	Floor int
+	// Weights are the importance of each token of the Document, in order. Without them each token
+	// weighs its length, so the score of the phrase is the runes matched over all its runes.
+	// The tokens without a weight weigh 1.
+	Weights []int
+}
+
+// WithPhraseScore evaluates the match score over the whole phrase instead of requiring each token of
+// the Document to reach the minimum match score: a heavily masked short word does not prevent the phrase
+// from matching as long as the phrase reaches config.Minimum and each token config.Floor. The token scores
+// are the percentage of their runes matched or the ones of the Scorer of the Document, if any.
+func WithPhraseScore(config PhraseScore) Option {
+	return func(d *Document) {
+		d.phrase = &config
+	}
+}
+
+// weight returns the importance of the token at the index, of the given length.
+func (p *PhraseScore) weight(index, length int) float64 {
+	if p.Weights == nil {
+		return float64(length)
+	}
+	if index < len(p.Weights) {
+		return float64(p.Weights[index])
+	}
+	return 1
+}
+
+// score returns the score of the runes a of the text compared to the runes b of the pattern
+// and whether they match, when the Document has a Scorer or a PhraseScore.
+func (d Document) score(a, b []rune, position int, path CheckPath) (float64, bool) {
+	var score float64
+	ok := true
+	if d.scorer != nil {
+		// the Scorer gets copies of the runes so the ones compared, often on the stack of the
+		// caller (ex: specialCheck), do not escape to the heap when there is no Scorer
+		var s int
+		s, ok = d.scorer.Score(d.compare(append([]rune(nil), a...), append([]rune(nil), b...), position, path))
+		score = float64(s)
+	} else if len(b) != 0 {
+		score = float64(d.compare(a, b, position, path).Matched()) * 100 / float64(len(b))
+	}
+	if d.phrase == nil {
+		return score, ok
+	}
+	return score, ok && score >= float64(d.phrase.Floor)
+}
+
+// phraseMatches reports whether the tokens at the positions reach the Minimum of the PhraseScore,
+// each one of them compared to the token of the refs at the same index.
+func (d Document) phraseMatches(refs [][]rune, tokens Tokens, positions []int) bool {
+	var total, weights float64
+	for i, ref := range refs {
+		score, _ := d.score(tokens.GetRunesByID(tokens.Ids[positions[i]]), ref, i, CheckToken)
+		w := d.phrase.weight(i, len(ref))
+		total += w * score
+		weights += w
+	}
+	return weights > 0 && reachesScore(total/weights, d.phrase.Minimum)
+}
+
+// reachesScore compares the scores ignoring the rounding errors of the weighted average.
+func reachesScore(score float64, minimum int) bool {
+	return score >= float64(minimum)-1e-9
+}
+
+// joinedMatches reports whether the runes a of the text reach the Minimum of the PhraseScore, if any,
+// compared to all the runes b of the pattern joined.
+func (d Document) joinedMatches(a, b []rune) bool {
+	if d.phrase == nil {
+		return true
+	}
+	score, _ := d.score(a, b, -1, CheckJoined)
+	return reachesScore(score, d.phrase.Minimum)
+}
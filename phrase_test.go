@@ -0,0 +1,36 @@
+package gomtch
+
+import "testing"
+
+func TestWithPhraseScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		text    string
+		pattern string
+		want    bool
+	}{
+		{"perToken", []Option{WithMinimumMatchScore(80)}, "buy cocaína t***y", "buy cocaína today", false},
+		{"phrase", []Option{WithPhraseScore(PhraseScore{Minimum: 80})}, "buy cocaína t***y", "buy cocaína today", true},
+		{"phraseExact", []Option{WithPhraseScore(PhraseScore{Minimum: 80})}, "buy c*c*í*a t***y", "buy cocaína today",
+			false},
+		{"phraseBoundary", []Option{WithPhraseScore(PhraseScore{Minimum: 80})}, "buy c*c*ína", "buy cocaína", true},
+		{"floor", []Option{WithPhraseScore(PhraseScore{Minimum: 60, Floor: 50})}, "buy cocaína t***y", "buy cocaína today",
+			false},
+		{"underFloor", []Option{WithPhraseScore(PhraseScore{Minimum: 60, Floor: 30})}, "buy cocaína t***y",
+			"buy cocaína today", true},
+		{"weights", []Option{WithPhraseScore(PhraseScore{Minimum: 80, Weights: []int{1, 5, 1}})},
+			"b*y cocaína t*d*y", "buy cocaína today", true},
+		{"weightsImportant", []Option{WithPhraseScore(PhraseScore{Minimum: 80, Weights: []int{1, 5, 1}})},
+			"buy c**a**a today", "buy cocaína today", false},
+		{"joined", []Option{WithPhraseScore(PhraseScore{Minimum: 80})}, "co ca í*a", "cocaína", true},
+		{"joinedUnderMinimum", []Option{WithPhraseScore(PhraseScore{Minimum: 80})}, "co c* í*a", "cocaína", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := locateIn(t, tt.text, tt.pattern, tt.opts...); got != tt.want {
+				t.Errorf("Locate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
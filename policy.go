@@ -0,0 +1,74 @@
+package gomtch
+
+// RunePolicy decides which runes of the text can stand for the runes of a pattern they are
+// not equal to, the wildcards used to mask a word (ex: "c*caína" or "c0caína" = cocaína).
+type RunePolicy struct {
+	// Strict reports whether the rune of the pattern must be matched exactly, no wildcard
+	// can stand for it.
+	Strict func(r rune) bool
+	// Wildcard reports whether the rune w of the text can stand for the rune r of the pattern
+	// in a token of more than one rune.
+	Wildcard func(w, r rune) bool
+	// SingleWildcard is the Wildcard of the tokens made of a single rune. If it is nil Wildcard is used.
+	SingleWildcard func(w, r rune) bool
+	// MaxWildcards is the number of wildcards a token may contain. Zero means no limit, the
+	// minimum match score still applies.
+	MaxWildcards int
+}
+
+// DefaultRunePolicy returns the policy used when none is given: numbers and numerical info
+// (%xª°º) are strict and, in a word, a letter can stand for a rune that is not a letter and the
+// other way around. A token made of a single rune that is not a letter, a number or numerical
+// info stands for any rune that is not strict.
+func DefaultRunePolicy() RunePolicy {
+	return RunePolicy{
+		Strict: func(r rune) bool {
+			return classOf(r).isNumerical()
+		},
+		Wildcard: func(w, r rune) bool {
+			return classOf(w).isLetter() != classOf(r).isLetter()
+		},
+		SingleWildcard: func(w, r rune) bool {
+			return classOf(w).isSpecial()
+		},
+	}
+}
+
+var defaultRunePolicy = DefaultRunePolicy()
+
+// WithRunePolicy replaces the rules deciding which runes of the text can stand for the runes of the
+// Document (ex: to let an x of the pattern be masked or to allow a single wildcard per token).
+// The nil functions of the policy are taken from DefaultRunePolicy.
+func WithRunePolicy(policy RunePolicy) Option {
+	return func(d *Document) {
+		if policy.Strict == nil {
+			policy.Strict = defaultRunePolicy.Strict
+		}
+		if policy.Wildcard == nil {
+			policy.Wildcard = defaultRunePolicy.Wildcard
+			if policy.SingleWildcard == nil {
+				policy.SingleWildcard = defaultRunePolicy.SingleWildcard
+			}
+		}
+		d.runePolicy = &policy
+	}
+}
+
+// policy returns the RunePolicy of the Document.
+func (d Document) policy() *RunePolicy {
+	if d.runePolicy == nil {
+		return &defaultRunePolicy
+	}
+	return d.runePolicy
+}
+
+// masks reports whether the rune w of the text can stand for the rune r of the pattern.
+func (p *RunePolicy) masks(w, r rune, single bool) bool {
+	if p.Strict(r) {
+		return false
+	}
+	if single && p.SingleWildcard != nil {
+		return p.SingleWildcard(w, r)
+	}
+	return p.Wildcard(w, r)
+}
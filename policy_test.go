@@ -0,0 +1,73 @@
+package gomtch
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestWithRunePolicy(t *testing.T) {
+	maskableX := RunePolicy{
+		Strict: func(r rune) bool {
+			return unicode.IsNumber(r)
+		},
+	}
+	noNumbers := RunePolicy{
+		Wildcard: func(w, r rune) bool {
+			return unicode.IsPunct(w) && unicode.IsLetter(r)
+		},
+	}
+	tests := []struct {
+		name    string
+		policy  *RunePolicy
+		text    string
+		pattern string
+		want    bool
+	}{
+		{"defaultMasked", nil, "c*caína", "cocaína", true},
+		{"defaultNumber", nil, "c0caína", "cocaína", true},
+		{"defaultX", nil, "ma*conha", "maxconha", false},
+		{"maskableX", &maskableX, "ma*conha", "maxconha", true},
+		{"maskableXNumber", &maskableX, "iphone 1*", "iphone 11", false},
+		{"numbersNotWildcards", &noNumbers, "c0caína", "cocaína", false},
+		{"punctWildcards", &noNumbers, "c.caína", "cocaína", true},
+		{"maxWildcards", &RunePolicy{MaxWildcards: 1}, "c*c*ína", "cocaína", false},
+		{"underMaxWildcards", &RunePolicy{MaxWildcards: 1}, "c*caína", "cocaína", true},
+		{"noLimit", nil, "c*c*ína", "cocaína", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithMinimumMatchScore(60)}
+			if tt.policy != nil {
+				opts = append(opts, WithRunePolicy(*tt.policy))
+			}
+			if _, got := locateIn(t, tt.text, tt.pattern, opts...); got != tt.want {
+				t.Errorf("Locate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunePolicy_SingleWildcard(t *testing.T) {
+	d, err := NewDocument("", WithRunePolicy(RunePolicy{
+		SingleWildcard: func(w, r rune) bool {
+			return w == '*'
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		a, b rune
+		want bool
+	}{
+		{'*', 'a', true},
+		{'.', 'a', false},
+		{'*', '1', false},
+		{'a', 'a', true},
+	}
+	for _, tt := range tests {
+		if got := d.CompareRune(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareRune(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package gomtch
+
+import (
+	"bufio"
+	"fmt"
+	"golang.org/x/text/language"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// RewriteRule is a context free substitution: the sequence From is read as To (ex: "ph" as "f").
+// The TextOnly rules are only applied to the text, not to the Document compared to it: a word
+// written with the sequence is not a disguise of the letter (ex: "burn" is not "bum").
+type RewriteRule struct {
+	From     string
+	To       string
+	TextOnly bool
+}
+
+var (
+	// VisualRewriteRules are the sequences drawn to look like a letter (ex: "rn" = m, "|<" = k).
+	// They are TextOnly.
+	VisualRewriteRules = []RewriteRule{
+		{`|\/|`, "m", true}, {"|-|", "h", true}, {`/\`, "a", true}, {"|<", "k", true}, {"|)", "d", true},
+		{"()", "o", true}, {"rn", "m", true}, {"vv", "w", true},
+	}
+	// PortugueseRewriteRules are the spellings of the same sounds in Brazilian Portuguese (ex: "ç" = ss).
+	PortugueseRewriteRules = []RewriteRule{
+		{"ç", "ss", false}, {"ch", "x", false}, {"ph", "f", false}, {"ck", "k", false}, {"qu", "k", false},
+		{"y", "i", false},
+	}
+	// EnglishRewriteRules are the spellings of the same sounds in English (ex: "ph" = f).
+	EnglishRewriteRules = []RewriteRule{
+		{"ight", "ite", false}, {"cks", "x", false}, {"ks", "x", false}, {"ph", "f", false}, {"ck", "k", false},
+		{"qu", "kw", false}, {"z", "s", false},
+	}
+	// SpanishRewriteRules are the spellings of the same sounds in Spanish (ex: "ll" = y).
+	SpanishRewriteRules = []RewriteRule{
+		{"ll", "y", false}, {"ph", "f", false}, {"ck", "k", false}, {"qu", "k", false}, {"v", "b", false},
+		{"z", "s", false},
+	}
+)
+
+// RewriteRulesFor returns the VisualRewriteRules and the rules of the language of the tag,
+// Portuguese, English or Spanish.
+func RewriteRulesFor(tag language.Tag) []RewriteRule {
+	rules := append([]RewriteRule{}, VisualRewriteRules...)
+	base, _ := tag.Base()
+	switch base.String() {
+	case "pt":
+		rules = append(rules, PortugueseRewriteRules...)
+	case "en":
+		rules = append(rules, EnglishRewriteRules...)
+	case "es":
+		rules = append(rules, SpanishRewriteRules...)
+	}
+	return rules
+}
+
+// ReadRewriteRules reads a rule per line, the sequence and what it is read as separated by white
+// spaces (ex: "ph f"), followed by "text" if the rule is TextOnly (ex: "rn m text"). The empty lines
+// and the ones starting with # are skipped.
+func ReadRewriteRules(r io.Reader) ([]RewriteRule, error) {
+	var rules []RewriteRule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 || (len(fields) == 3 && fields[2] != "text") {
+			return nil, fmt.Errorf("invalid rewrite rule at line %d: %q", line, text)
+		}
+		rules = append(rules, RewriteRule{From: fields[0], To: fields[1], TextOnly: len(fields) == 3})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// WithRewriteRules compares the tokens that do not match also after rewriting them, the ones of the text
+// with all the rules and the ones of the Document with the rules that are not TextOnly (ex: "pharmacia" =
+// farmácia and "vvord" = word). The rules are applied to the normalized tokens in a single pass, the longest
+// sequences first, and the tokens of the Document are rewritten once, when it is created.
+func WithRewriteRules(rules ...RewriteRule) Option {
+	return func(d *Document) {
+		all := append(append([]RewriteRule{}, d.rewriteRules...), rules...)
+		sort.SliceStable(all, func(i, j int) bool {
+			return utf8.RuneCountInString(all[i].From) > utf8.RuneCountInString(all[j].From)
+		})
+		pairs := make([]string, 0, len(all)*2)
+		var refPairs []string
+		for _, r := range all {
+			if r.From == "" {
+				d.optError = fmt.Errorf("empty rewrite rule to %q", r.To)
+				return
+			}
+			pairs = append(pairs, r.From, r.To)
+			if !r.TextOnly {
+				refPairs = append(refPairs, r.From, r.To)
+			}
+		}
+		d.rewriteRules = all
+		d.rewriter = strings.NewReplacer(pairs...)
+		d.refRewriter = nil
+		if refPairs != nil {
+			d.refRewriter = strings.NewReplacer(refPairs...)
+		}
+	}
+}
+
+// rewrite returns the runes of the text rewritten by the rules and whether they changed.
+func (d Document) rewrite(runes []rune) ([]rune, bool) {
+	return rewriteRunes(d.rewriter, runes)
+}
+
+// rewriteRef returns the runes of the Document rewritten by the rules that are not TextOnly and whether
+// they changed. The tokens of the Document and all of them joined were rewritten when it was created.
+func (d Document) rewriteRef(runes []rune) ([]rune, bool) {
+	if d.refRewriter == nil {
+		return runes, false
+	}
+	if r, ok := d.rewrittenRefs.lookup(runes); ok {
+		return r, !sameRunes(r, runes)
+	}
+	return rewriteRunes(d.refRewriter, runes)
+}
+
+func rewriteRunes(r *strings.Replacer, runes []rune) ([]rune, bool) {
+	s := string(runes)
+	rewritten := r.Replace(s)
+	if rewritten == s {
+		return runes, false
+	}
+	return []rune(rewritten), true
+}
+
+// rewrittenRefs are the tokens of a Document and all of them joined rewritten by the rules.
+type rewrittenRefs struct {
+	refs, rewritten [][]rune
+}
+
+// newRewrittenRefs rewrites the refs with r.
+func newRewrittenRefs(r *strings.Replacer, refs ...[]rune) rewrittenRefs {
+	rr := rewrittenRefs{refs: refs}
+	for _, ref := range refs {
+		rewritten, _ := rewriteRunes(r, ref)
+		rr.rewritten = append(rr.rewritten, rewritten)
+	}
+	return rr
+}
+
+// lookup returns the runes rewritten if they are one of the refs, the same slice and not a copy.
+func (rr rewrittenRefs) lookup(runes []rune) ([]rune, bool) {
+	if len(runes) == 0 {
+		return nil, false
+	}
+	for i, ref := range rr.refs {
+		if len(ref) == len(runes) && &ref[0] == &runes[0] {
+			return rr.rewritten[i], true
+		}
+	}
+	return nil, false
+}
+
+// sameRunes reports whether a and b are the same slice.
+func sameRunes(a, b []rune) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
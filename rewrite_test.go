@@ -0,0 +1,85 @@
+package gomtch
+
+import (
+	"golang.org/x/text/language"
+	"strings"
+	"testing"
+)
+
+func TestWithRewriteRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []RewriteRule
+		text    string
+		pattern string
+		want    bool
+	}{
+		{"withoutRules", nil, "pharmacia", "farmacia", false},
+		{"longer", RewriteRulesFor(language.BrazilianPortuguese), "pharmacia", "farmacia", true},
+		{"shorter", RewriteRulesFor(language.BrazilianPortuguese), "farmacia", "pharmacia", true},
+		{"cedilla", RewriteRulesFor(language.BrazilianPortuguese), "cabessa", "cabeça", true},
+		{"chToX", RewriteRulesFor(language.BrazilianPortuguese), "xeirar", "cheirar", true},
+		{"quToK", RewriteRulesFor(language.BrazilianPortuguese), "compre aki", "compre aqui", true},
+		{"visual", RewriteRulesFor(language.BrazilianPortuguese), "vvord", "word", true},
+		{"visualM", RewriteRulesFor(language.English), "rnarijuana", "marijuana", true},
+		{"visualK", RewriteRulesFor(language.English), "ma|<e", "make", true},
+		{"visualTextOnly", RewriteRulesFor(language.English), "bum", "burn", false},
+		{"visualPatternAsWritten", RewriteRulesFor(language.English), "burn", "burn", true},
+		{"english", RewriteRulesFor(language.English), "nite", "night", true},
+		{"englishX", RewriteRulesFor(language.English), "sux", "sucks", true},
+		{"spanish", RewriteRulesFor(language.Spanish), "yamar", "llamar", true},
+		{"otherLanguage", RewriteRulesFor(language.Spanish), "cabessa", "cabeça", false},
+		{"masked", RewriteRulesFor(language.BrazilianPortuguese), "ph*rmacia", "farmacia", false},
+		{"custom", []RewriteRule{{"0", "o", false}, {"ee", "i", false}}, "speed", "spid", true},
+		{"phrase", RewriteRulesFor(language.BrazilianPortuguese), "compre na pharmacia", "na farmacia", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.rules != nil {
+				opts = append(opts, WithRewriteRules(tt.rules...))
+			}
+			if _, got := locateIn(t, tt.text, tt.pattern, opts...); got != tt.want {
+				t.Errorf("Locate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRewriteRules(t *testing.T) {
+	rules, err := ReadRewriteRules(strings.NewReader("# leet\n\n  ph f\n|< k text\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RewriteRule{{"ph", "f", false}, {"|<", "k", true}}
+	if len(rules) != len(want) || rules[0] != want[0] || rules[1] != want[1] {
+		t.Errorf("ReadRewriteRules() = %v, want %v", rules, want)
+	}
+	if _, err := ReadRewriteRules(strings.NewReader("ph f\nck\n")); err == nil ||
+		err.Error() != `invalid rewrite rule at line 2: "ck"` {
+		t.Errorf("ReadRewriteRules() error = %v", err)
+	}
+	if _, err := ReadRewriteRules(strings.NewReader("ph f both\n")); err == nil {
+		t.Error("ReadRewriteRules() error = nil")
+	}
+	if _, err := NewDocument("", WithRewriteRules(RewriteRule{To: "f"})); err == nil {
+		t.Error("WithRewriteRules() error = nil")
+	}
+}
+
+func TestDocument_rewriteRef(t *testing.T) {
+	d, err := NewDocument("na pharmacia", WithRewriteRules(RewriteRulesFor(language.English)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, changed := d.rewriteRef(d.runes[1])
+	if string(got) != "farmacia" || !changed {
+		t.Errorf("rewriteRef() = %q %v, want farmacia true", string(got), changed)
+	}
+	if !sameRunes(got, d.rewrittenRefs.rewritten[1]) {
+		t.Error("rewriteRef() rewrote the token again, want the one rewritten when the Document was created")
+	}
+	if got, _ := d.rewriteRef([]rune("burn")); string(got) != "burn" {
+		t.Errorf("rewriteRef() = %q, want the TextOnly rules skipped", string(got))
+	}
+}
@@ -0,0 +1,61 @@
+package gomtch
+
+import "unicode"
+
+// runeClass holds the kinds of a rune that matter to the comparison rules.
+// A rune may be of more than one kind (ex: x is both a letter and numerical info).
+// A rune of no kind is special.
+type runeClass uint8
+
+const (
+	classLetter runeClass = 1 << iota
+	classNumber
+	classNumericalInfo
+)
+
+// latinClasses is the class of each rune of the Latin-1 range, where most of the runes
+// compared are. Other runes are classified by the unicode package.
+var latinClasses [256]runeClass
+
+func init() {
+	for r := range latinClasses {
+		latinClasses[r] = classifyRune(rune(r))
+	}
+}
+
+func classifyRune(r rune) runeClass {
+	var c runeClass
+	if unicode.IsLetter(r) {
+		c |= classLetter
+	}
+	if unicode.IsNumber(r) {
+		c |= classNumber
+	}
+	for _, n := range numericalInfo {
+		if r == n {
+			c |= classNumericalInfo
+		}
+	}
+	return c
+}
+
+func classOf(r rune) runeClass {
+	if r >= 0 && r < rune(len(latinClasses)) {
+		return latinClasses[r]
+	}
+	return classifyRune(r)
+}
+
+func (c runeClass) isLetter() bool {
+	return c&classLetter != 0
+}
+
+// isNumerical reports whether the rune is a number or numerical info.
+// Those must always match exactly.
+func (c runeClass) isNumerical() bool {
+	return c&(classNumber|classNumericalInfo) != 0
+}
+
+func (c runeClass) isSpecial() bool {
+	return c == 0
+}
@@ -0,0 +1,35 @@
+package gomtch
+
+import (
+	"testing"
+	"unicode"
+)
+
+func Test_classOf(t *testing.T) {
+	for r := rune(0); r < 0x3000; r++ {
+		c := classOf(r)
+		if c.isLetter() != unicode.IsLetter(r) {
+			t.Errorf("classOf(%q).isLetter() = %v", r, c.isLetter())
+		}
+		numerical := unicode.IsNumber(r)
+		for _, n := range numericalInfo {
+			numerical = numerical || r == n
+		}
+		if c.isNumerical() != numerical {
+			t.Errorf("classOf(%q).isNumerical() = %v", r, c.isNumerical())
+		}
+		if c.isSpecial() != (!unicode.IsLetter(r) && !numerical) {
+			t.Errorf("classOf(%q).isSpecial() = %v", r, c.isSpecial())
+		}
+	}
+}
+
+func BenchmarkClassOf(b *testing.B) {
+	text := []rune("Escrito nos raros intervalos de folga de uma carreira fatigante, 29 % dos quais 23 %")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, r := range text {
+			classOf(r)
+		}
+	}
+}
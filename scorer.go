@@ -0,0 +1,120 @@
+package gomtch
+
+import "fmt"
+
+// CheckPath is the way a token of the pattern was compared to the text.
+type CheckPath int
+
+const (
+	// CheckRune compares tokens made of a single rune with CompareRune.
+	CheckRune CheckPath = iota
+	// CheckToken compares a token of the pattern to a token of the text.
+	CheckToken
+	// CheckJoined compares the tokens of the pattern joined to sequential tokens of the text,
+	// for the words split by spaces or special characters (ex: "co.ca.ína").
+	CheckJoined
+)
+
+var checkPathNames = map[CheckPath]string{
+	CheckRune:   "rune",
+	CheckToken:  "token",
+	CheckJoined: "joined tokens",
+}
+
+func (p CheckPath) String() string {
+	if name, ok := checkPathNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("check(%d)", int(p))
+}
+
+// Comparison is the result of comparing the runes of the text to the runes of the pattern, both
+// of the same length. The slices are only valid while the Scorer is called.
+type Comparison struct {
+	Text    []rune
+	Pattern []rune
+	// Exact is the number of runes equal in the text and in the pattern.
+	Exact int
+	// Substitutions are the positions of the runes matched ignoring the case WithCaseInsensitiveMatching.
+	// The transformers (ex: NewSkeleton) fold the runes before they are compared and the tokens found
+	// with the rewrite rules are compared rewritten, so the runes they change are Exact.
+	Substitutions []int
+	// Wildcards are the positions of the runes of the text standing for the ones of the pattern.
+	Wildcards []int
+	// Position is the index of the token in the pattern, -1 if it is not known (ex: CheckJoined).
+	Position int
+	Path     CheckPath
+}
+
+// Matched returns the number of runes of the pattern matched exactly or through an equivalence.
+func (c Comparison) Matched() int {
+	return len(c.Pattern) - len(c.Wildcards)
+}
+
+// FirstMatched reports whether the first rune of the pattern was matched, not masked.
+func (c Comparison) FirstMatched() bool {
+	return len(c.Pattern) != 0 && !intIn(0, c.Wildcards)
+}
+
+// LastMatched reports whether the last rune of the pattern was matched, not masked.
+func (c Comparison) LastMatched() bool {
+	return len(c.Pattern) != 0 && !intIn(len(c.Pattern)-1, c.Wildcards)
+}
+
+func intIn(v int, values []int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Scorer scores the comparison of a token of the pattern and decides whether it matches.
+// The score goes from 0 to 100.
+type Scorer interface {
+	Score(c Comparison) (int, bool)
+}
+
+// ScorerFunc adapts a function to a Scorer.
+type ScorerFunc func(c Comparison) (int, bool)
+
+func (f ScorerFunc) Score(c Comparison) (int, bool) {
+	return f(c)
+}
+
+// NewMinimumScorer returns the Scorer used by WithMinimumMatchScore: the score is the
+// percentage of the runes matched and it must be at least the given one.
+func NewMinimumScorer(score int) Scorer {
+	return ScorerFunc(func(c Comparison) (int, bool) {
+		if len(c.Pattern) == 0 {
+			return 0, false
+		}
+		return c.Matched() * 100 / len(c.Pattern), c.Matched() >= score*len(c.Pattern)/100
+	})
+}
+
+// WithScorer decides with s whether each token of the text matches the one of the Document,
+// instead of the minimum match score. The comparison given to s tells which runes were masked
+// and how the token was compared (ex: to require the first and last letters to match).
+func WithScorer(s Scorer) Option {
+	return func(d *Document) {
+		d.setMatchScore("WithScorer", nil, s)
+	}
+}
+
+// compare returns the Comparison of the runes a of the text to the runes b of the pattern.
+func (d Document) compare(a, b []rune, position int, path CheckPath) Comparison {
+	c := Comparison{Text: a, Pattern: b, Position: position, Path: path}
+	for i, v := range b {
+		switch {
+		case a[i] == v:
+			c.Exact++
+		case d.equalRune(v, a[i]):
+			c.Substitutions = append(c.Substitutions, i)
+		default:
+			c.Wildcards = append(c.Wildcards, i)
+		}
+	}
+	return c
+}
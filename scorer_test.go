@@ -0,0 +1,115 @@
+package gomtch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithScorer(t *testing.T) {
+	firstLastExact := ScorerFunc(func(c Comparison) (int, bool) {
+		score, ok := NewMinimumScorer(60).Score(c)
+		return score, ok && c.FirstMatched() && c.LastMatched()
+	})
+	shortExact := ScorerFunc(func(c Comparison) (int, bool) {
+		if len(c.Pattern) <= 4 {
+			return NewMinimumScorer(100).Score(c)
+		}
+		return NewMinimumScorer(60).Score(c)
+	})
+	tests := []struct {
+		name    string
+		scorer  Scorer
+		text    string
+		pattern string
+		want    bool
+	}{
+		{"minimum", NewMinimumScorer(60), "c*c*ína", "cocaína", true},
+		{"minimumTooMasked", NewMinimumScorer(60), "c****na", "cocaína", false},
+		{"firstLastExact", firstLastExact, "c*c*ína", "cocaína", true},
+		{"firstMasked", firstLastExact, "*oca*na", "cocaína", false},
+		{"lastMasked", firstLastExact, "coc*ín*", "cocaína", false},
+		{"shortExact", shortExact, "b*ck", "back", false},
+		{"shortExactEqual", shortExact, "back", "back", true},
+		{"long", shortExact, "backd**r", "backdoor", true},
+		{"phrase", shortExact, "buy b*ck", "buy back", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := locateIn(t, tt.text, tt.pattern, WithScorer(tt.scorer)); got != tt.want {
+				t.Errorf("Locate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScorer_Comparison(t *testing.T) {
+	var got []Comparison
+	recorder := ScorerFunc(func(c Comparison) (int, bool) {
+		c.Text, c.Pattern = append([]rune(nil), c.Text...), append([]rune(nil), c.Pattern...)
+		got = append(got, c)
+		return NewMinimumScorer(60).Score(c)
+	})
+	pattern, err := NewDocument("buy Cocaína", WithScorer(recorder), WithCaseInsensitiveMatching())
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := NewDocument("buy c*caÍna")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := pattern.Compare(text.Mapped()); !ok {
+		t.Fatal("Compare() = false")
+	}
+	want := []Comparison{
+		{Text: []rune("buy"), Pattern: []rune("buy"), Exact: 3, Position: 0, Path: CheckToken},
+		{Text: []rune("c*caÍna"), Pattern: []rune("Cocaína"), Exact: 4, Substitutions: []int{0, 4},
+			Wildcards: []int{1}, Position: 1, Path: CheckToken},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Score() got %+v, want %+v", got, want)
+	}
+	if s, _ := NewMinimumScorer(60).Score(got[1]); s != 85 {
+		t.Errorf("Score() = %d, want 85", s)
+	}
+}
+
+func TestWithScorer_MatchScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		text    string
+		pattern string
+		want    int
+	}{
+		{"exact", nil, "compre cocaína", "cocaína", 100},
+		{"masked", []Option{WithMinimumMatchScore(80)}, "compre c*caína", "cocaína", 85},
+		{"phrase", []Option{WithMinimumMatchScore(50)}, "buy c*c*ína", "buy cocaína", 80},
+		{"scorer", []Option{WithScorer(ScorerFunc(func(c Comparison) (int, bool) {
+			return 42, true
+		}))}, "compre c*caína", "cocaína", 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := NewDocument(tt.pattern, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, err := NewDocument(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches := text.Find(pattern)
+			if len(matches) != 1 || matches[0].Score != tt.want {
+				t.Errorf("Find() = %+v, want Score %d", matches, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPath_String(t *testing.T) {
+	for p, want := range map[CheckPath]string{CheckRune: "rune", CheckJoined: "joined tokens", CheckPath(9): "check(9)"} {
+		if got := p.String(); got != want {
+			t.Errorf("String() = %v, want %v", got, want)
+		}
+	}
+}
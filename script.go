@@ -0,0 +1,239 @@
+package gomtch
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// noSpaceScripts are the scripts usually written without spaces between words.
+var noSpaceScripts = []*unicode.RangeTable{
+	unicode.Han,
+	unicode.Hiragana,
+	unicode.Katakana,
+	unicode.Thai,
+	unicode.Lao,
+	unicode.Khmer,
+	unicode.Myanmar,
+}
+
+func isNoSpaceScript(r rune) bool {
+	return unicode.In(r, noSpaceScripts...)
+}
+
+// isMark reports whether the rune combines with the rune before it.
+func isMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Mc, unicode.Me)
+}
+
+type scriptTokenizer struct {
+	base Tokenizer
+}
+
+// NewScriptTokenizer splits the tokens of base that contain text in scripts written without spaces
+// between words (Chinese, Japanese, Thai, Lao, Khmer and Myanmar) in one token per character, along with
+// its combining marks. Text in other scripts is kept as base tokenized it.
+// The characters are matched as sequential tokens, like a word split with spaces (ex: c o r p o r a),
+// so a pattern must be tokenized without it to have its score computed over the whole term.
+func NewScriptTokenizer(base Tokenizer) Tokenizer {
+	return scriptTokenizer{base: base}
+}
+
+func (s scriptTokenizer) Tokenize(text string) []string {
+	var tokens []string
+	for _, token := range s.base.Tokenize(text) {
+		if strings.IndexFunc(token, isNoSpaceScript) == -1 {
+			tokens = append(tokens, token)
+			continue
+		}
+		tokens = append(tokens, splitNoSpaceScript(token)...)
+	}
+	return tokens
+}
+
+// splitNoSpaceScript splits each character of a no space script from the token.
+func splitNoSpaceScript(token string) []string {
+	var tokens []string
+	start := 0
+	inScript := false
+	for i, r := range token {
+		if isMark(r) {
+			continue
+		}
+		script := isNoSpaceScript(r)
+		if i > start && (script || inScript) {
+			tokens = append(tokens, token[start:i])
+			start = i
+		}
+		inScript = script
+	}
+	if start < len(token) {
+		tokens = append(tokens, token[start:])
+	}
+	return tokens
+}
+
+// WithScriptSegmentation splits each character of the scripts written without spaces in a token of
+// its own, after the text is split by the tokenizer of the Document (see NewScriptTokenizer).
+// It is meant for the scanned Document.
+func WithScriptSegmentation() Option {
+	return func(d *Document) {
+		d.scriptSegmentation = true
+	}
+}
+
+// tokenize splits the text with t, and the scripts written without spaces WithScriptSegmentation.
+func (d Document) tokenize(t Tokenizer) []string {
+	if d.scriptSegmentation {
+		t = NewScriptTokenizer(t)
+	}
+	return t.Tokenize(d.Text)
+}
+
+// scriptRange is a range of runes of a single script, with no name for the runes shared by many
+// scripts (Common and Inherited).
+type scriptRange struct {
+	lo, hi rune
+	name   string
+}
+
+// scriptRanges are the ranges of all the scripts of unicode.Scripts sorted, so the script of
+// a rune is found with a single binary search.
+var scriptRanges = newScriptRanges()
+
+func newScriptRanges() []scriptRange {
+	var ranges []scriptRange
+	add := func(lo, hi, stride rune, name string) {
+		if stride == 1 {
+			ranges = append(ranges, scriptRange{lo: lo, hi: hi, name: name})
+			return
+		}
+		// the runes between the ones of a strided range may belong to other scripts
+		for r := lo; r <= hi; r += stride {
+			ranges = append(ranges, scriptRange{lo: r, hi: r, name: name})
+		}
+	}
+	for name, table := range unicode.Scripts {
+		if name == "Common" || name == "Inherited" {
+			name = ""
+		}
+		for _, r := range table.R16 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride), name)
+		}
+		for _, r := range table.R32 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride), name)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].lo < ranges[j].lo
+	})
+	return ranges
+}
+
+// scriptOf returns the name of the script of the rune or an empty string for the runes
+// shared by many scripts (punctuation, digits, combining marks...).
+func scriptOf(r rune) string {
+	if r < utf8.RuneSelf {
+		if c := r | 0x20; 'a' <= c && c <= 'z' {
+			return "Latin"
+		}
+		return ""
+	}
+	i := sort.Search(len(scriptRanges), func(i int) bool {
+		return scriptRanges[i].hi >= r
+	})
+	if i < len(scriptRanges) && scriptRanges[i].lo <= r {
+		return scriptRanges[i].name
+	}
+	return ""
+}
+
+// allowedScriptSets are the scripts that may be used together in a single token, following the
+// highly restrictive level of UTS #39.
+var allowedScriptSets = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// Scripts returns the scripts used by the letters of the token, in the order they appear.
+func Scripts(token string) []string {
+	var scripts []string
+	for _, r := range token {
+		script := scriptOf(r)
+		if script == "" || stringIn(script, scripts) {
+			continue
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts
+}
+
+// IsMixedScript reports whether the token is written with letters of scripts that are not used
+// together (ex: "сосаina" with Cyrillic and Latin letters), a common way to hide words.
+func IsMixedScript(token string) bool {
+	scripts := Scripts(token)
+	if len(scripts) < 2 {
+		return false
+	}
+Outer:
+	for _, allowed := range allowedScriptSets {
+		for _, script := range scripts {
+			if !stringIn(script, allowed) {
+				continue Outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func stringIn(s string, set []string) bool {
+	for _, v := range set {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// WithMixedScriptDetection reports a Signal for each token written with letters of scripts
+// that are not used together. It runs before the folding options so the scripts are the ones
+// in the text given.
+func WithMixedScriptDetection() Option {
+	return func(d *Document) {
+		d.addStep(StageAnalysis, "WithMixedScriptDetection", func(d *Document) {
+			eachField(d.Text, func(offset int, token string) {
+				if IsMixedScript(token) {
+					d.addSignal(Signal{
+						Kind:   SignalMixedScript,
+						Offset: d.originalOffset(offset),
+						Text:   token,
+						Detail: strings.Join(Scripts(token), "+"),
+					})
+				}
+			})
+		})
+	}
+}
+
+// eachField calls f for each token of the text split on white spaces, with its byte offset.
+func eachField(text string, f func(offset int, token string)) {
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				f(start, text[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		f(start, text[start:])
+	}
+}
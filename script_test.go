@@ -0,0 +1,141 @@
+package gomtch
+
+import (
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+func TestScriptTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"latin", "coca cocaína", []string{"coca", "cocaína"}},
+		{"chinese", "我想买可卡因。", []string{"我", "想", "买", "可", "卡", "因", "。"}},
+		{"mixed", "buy可卡因now 123", []string{"buy", "可", "卡", "因", "now", "123"}},
+		{"japanese", "コカインを買う", []string{"コ", "カ", "イ", "ン", "を", "買", "う"}},
+		{"thaiMarks", "ขายโคเคน", []string{"ข", "า", "ย", "โ", "ค", "เ", "ค", "น"}},
+		{"thaiCombining", "ดิน", []string{"ดิ", "น"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewScriptTokenizer(NewWhitespaceTokenizer()).Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithScriptSegmentation_Tokenizer(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithScriptSegmentation(), WithTokenizer(NewJoinedTokenizer())},
+		{WithTokenizer(NewJoinedTokenizer()), WithScriptSegmentation()},
+	} {
+		d, err := NewDocument("buy 可卡因 now", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"buy", "可", "卡", "因", "now"}; !reflect.DeepEqual(d.Tokens, want) {
+			t.Errorf("Tokens = %q, want %q", d.Tokens, want)
+		}
+		if len(d.Warnings()) != 0 {
+			t.Errorf("Warnings() = %v", d.Warnings())
+		}
+	}
+}
+
+func TestDocument_FindNoSpaceScripts(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		pattern string
+		score   int
+		want    []Match
+	}{
+		{"chinese", "我想买可卡因。", "可卡因", 100, []Match{{Sequence: []rune("可 卡 因"), Start: 3, End: 6, Score: 100}}},
+		{"chineseRepeated", "我想买可可卡因。", "可卡因", 100, []Match{{Sequence: []rune("可 卡 因"), Start: 4, End: 7, Score: 100}}},
+		{"chineseWildcard", "我想买可*因。", "可卡因", 60, []Match{{Sequence: []rune("可 * 因"), Start: 3, End: 6, Score: 66}}},
+		{"chineseWildcardScore", "我想买可*因。", "可卡因", 100, nil},
+		{"chineseNotFound", "我想买可乐。", "可卡因", 60, nil},
+		{"japanese", "コカインを買う", "コカイン", 100, []Match{{Sequence: []rune("コ カ イ ン"), Start: 0, End: 4, Score: 100}}},
+		{"thai", "ขายโคเคนราคาถูก", "โคเคน", 100, []Match{{Sequence: []rune("โ ค เ ค น"), Start: 3, End: 8, Score: 100}}},
+		{"latinAlongside", "买 cocaina 可卡因", "cocaina", 100, []Match{{Sequence: []rune("cocaina"), Start: 1, End: 2, Score: 100}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := NewDocument(tt.text, WithScriptSegmentation())
+			if err != nil {
+				t.Fatal(err)
+			}
+			pattern, err := NewDocument(tt.pattern, WithMinimumMatchScore(tt.score))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := text.Find(pattern); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMixedScript(t *testing.T) {
+	tests := []struct {
+		token   string
+		scripts []string
+		want    bool
+	}{
+		{"cocaina", []string{"Latin"}, false},
+		{"сосаіnа", []string{"Cyrillic", "Latin"}, true},
+		{"сосаіна", []string{"Cyrillic"}, false},
+		{"cοcaina", []string{"Latin", "Greek"}, true},
+		{"h4rd!", []string{"Latin"}, false},
+		{"コカインを買う", []string{"Katakana", "Hiragana", "Han"}, false},
+		{"buy可卡因", []string{"Latin", "Han"}, false},
+		{"123", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			if got := Scripts(tt.token); !reflect.DeepEqual(got, tt.scripts) {
+				t.Errorf("Scripts() = %v, want %v", got, tt.scripts)
+			}
+			if got := IsMixedScript(tt.token); got != tt.want {
+				t.Errorf("IsMixedScript() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMixedScriptDetection(t *testing.T) {
+	d, err := NewDocument("compre <b>сосаіnа</b> aqui",
+		WithMixedScriptDetection(), WithHMTLParsing(), WithTransform(NewSkeleton()), WithOffsetTracking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Signal{{Kind: SignalMixedScript, Offset: 10, Text: "сосаіnа", Detail: "Cyrillic+Latin"}}
+	if got := d.Signals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Signals() = %v, want %v", got, want)
+	}
+	pattern, err := NewDocument("cocaina")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Scan(pattern); !reflect.DeepEqual(got, Matches{0: []rune("cocaina")}) {
+		t.Errorf("Scan() = %v", got)
+	}
+}
+
+func Test_scriptOf(t *testing.T) {
+	for r := rune(0); r <= unicode.MaxRune; r += 61 {
+		want := ""
+		for name, table := range unicode.Scripts {
+			if name != "Common" && name != "Inherited" && unicode.Is(table, r) {
+				want = name
+			}
+		}
+		if got := scriptOf(r); got != want {
+			t.Errorf("scriptOf(%U) = %q, want %q", r, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package gomtch
+
+import "fmt"
+
+// SignalKind identifies what a Signal reports.
+type SignalKind int
+
+const (
+	// SignalMixedScript is a token written with letters of scripts that are not used together.
+	SignalMixedScript SignalKind = iota
+	// SignalInvisible is a class of characters that are not displayed found in the text.
+	SignalInvisible
+	// SignalHiddenText is a text of an HTML document that is not displayed.
+	SignalHiddenText
+)
+
+var signalNames = map[SignalKind]string{
+	SignalMixedScript: "mixed script",
+	SignalInvisible:   "invisible characters",
+	SignalHiddenText:  "hidden text",
+}
+
+func (k SignalKind) String() string {
+	if name, ok := signalNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("signal(%d)", int(k))
+}
+
+// Signal is a suspicious characteristic found while normalizing the text, usually a sign
+// that someone tried to hide something in it. Offset is the byte offset where Text comes from
+// in the Original text with WithOffsetTracking, in the text given to the step that found it
+// otherwise. The Signals found in a decoded Segment tell it and their Offset is in the Text of
+// the Segment.
+type Signal struct {
+	Kind    SignalKind
+	Offset  int
+	Text    string
+	Detail  string
+	Segment *Segment
+}
+
+func (s Signal) String() string {
+	if s.Segment != nil {
+		return fmt.Sprintf("%s at offset %d of the %s: %q %s", s.Kind, s.Offset, s.Segment, s.Text, s.Detail)
+	}
+	return fmt.Sprintf("%s at offset %d: %q %s", s.Kind, s.Offset, s.Text, s.Detail)
+}
+
+func (d *Document) addSignal(s Signal) {
+	d.signals = append(d.signals, s)
+}
+
+// Signals returns the suspicious characteristics found while normalizing the text of the Document.
+func (d Document) Signals() []Signal {
+	return d.signals
+}
@@ -0,0 +1,62 @@
+package gomtch
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+)
+
+// latinConfusables maps the characters of other scripts to the Latin letters they look like. It is a
+// selection of the entries of the Unicode confusables data (UTS #39, confusables.txt) whose prototype
+// is a basic Latin letter, not the full data. The Latin letters themselves are not mapped to their
+// prototypes (ex: "I" to "l"), so Latin text is left unchanged and the Cyrillic "І" is read as "I".
+// The lookalikes of other characters are left out (ex: the Cyrillic "к" is confusable with "ᴋ", not "k").
+var latinConfusables = map[rune]string{
+	// Cyrillic
+	'а': "a", 'с': "c", 'ԁ': "d", 'е': "e", 'һ': "h", 'і': "i", 'ј': "j", 'ӏ': "l", 'о': "o", 'р': "p",
+	'ԛ': "q", 'ѕ': "s", 'ѵ': "v", 'ԝ': "w", 'х': "x", 'у': "y", 'ү': "y",
+	'А': "A", 'В': "B", 'С': "C", 'Ԁ': "D", 'Е': "E", 'Ԍ': "G", 'Н': "H", 'І': "I", 'Ӏ': "I", 'Ј': "J",
+	'К': "K", 'М': "M", 'О': "O", 'Р': "P", 'Ԛ': "Q", 'Ѕ': "S", 'Т': "T", 'Ѵ': "V", 'Ԝ': "W",
+	'Х': "X", 'У': "Y", 'Ү': "Y", 'Һ': "H", 'Ь': "b",
+	// Greek
+	'α': "a", 'γ': "y", 'ι': "i", 'ν': "v", 'ο': "o", 'ρ': "p", 'σ': "o", 'χ': "x", 'ϲ': "c", 'ϳ': "j",
+	'η': "n", 'ɩ': "i",
+	'Α': "A", 'Β': "B", 'Ε': "E", 'Ζ': "Z", 'Η': "H", 'Ι': "I", 'Κ': "K", 'Μ': "M", 'Ν': "N", 'Ο': "O",
+	'Ρ': "P", 'Τ': "T", 'Υ': "Y", 'Χ': "X", 'Ϲ': "C", 'Ϳ': "J",
+	// Armenian
+	'գ': "q", 'զ': "q", 'հ': "h", 'ո': "n", 'ս': "u", 'ց': "g", 'օ': "o", 'Օ': "O", 'Ս': "U",
+	'Լ': "L", 'Տ': "S",
+	// Cherokee
+	'Ꭺ': "A", 'Ᏼ': "B", 'Ꮯ': "C", 'Ꭰ': "D", 'Ꭼ': "E", 'Ꮐ': "G", 'Ꮋ': "H", 'Ꭻ': "J", 'Ꮶ': "K", 'Ꮮ': "L",
+	'Ꮇ': "M", 'Ꮲ': "P", 'Ꮢ': "R", 'Ꮪ': "S", 'Ꭲ': "T", 'Ꮩ': "V", 'Ꮃ': "W", 'Ꮓ': "Z",
+	// Latin lookalikes outside the basic letters
+	'ı': "i", 'ȷ': "j", 'ɑ': "a", 'ɡ': "g",
+	'ℓ': "l", 'ⅰ': "i", 'ⅼ': "l", 'ⅽ': "c", 'ⅾ': "d", 'ⅿ': "m", 'ⅴ': "v", 'ⅹ': "x",
+	'Ⅰ': "I", 'Ⅴ': "V", 'Ⅹ': "X", 'Ⅼ': "L", 'Ⅽ': "C", 'Ⅾ': "D", 'Ⅿ': "M",
+}
+
+// Skeleton replaces the characters of other scripts that look like Latin letters with those letters
+// (ex: "сосаіnа" written with Cyrillic letters = cocaina). It is not the skeleton of UTS #39: its
+// mapping is a partial, hand-picked one that only targets the basic Latin letters, so the other
+// confusables of Unicode are left as they are. The text is decomposed (NFD) before the mapping and
+// composed (NFC) after it, so accented lookalikes keep their accents.
+type Skeleton struct {
+	confusables map[rune]string
+}
+
+func NewSkeleton() *Skeleton {
+	return &Skeleton{confusables: latinConfusables}
+}
+
+func (s Skeleton) Transform(text string) (string, error) {
+	decomposed := norm.NFD.String(text)
+	var b strings.Builder
+	b.Grow(len(decomposed))
+	for _, r := range decomposed {
+		if v, ok := s.confusables[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String()), nil
+}
@@ -0,0 +1,194 @@
+package gomtch
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Stage identifies the point of the normalization pipeline where an Option runs.
+// Normalization options are not applied in the order they are given to NewDocument,
+// instead each one declares its Stage and all of them run in the order of the values below.
+// Options of the same Stage run in the order they were given. The values are spaced so a
+// stage can be added between two others without changing them.
+type Stage int
+
+const (
+	// StageDecode turns encoded input (charsets, entities, escapes) into plain text.
+	StageDecode Stage = 0
+	// StageMarkup extracts the text from markup such as HTML.
+	StageMarkup Stage = 10
+	// StageInvisibleRemoval removes the characters that are not displayed (ex: zero width spaces).
+	StageInvisibleRemoval Stage = 20
+	// StageAnalysis inspects the extracted text before it is folded, without changing it.
+	StageAnalysis Stage = 30
+	// StageCompatibilityFolding folds the styled forms of the characters (ex: 𝐜, ｃ, ⓒ = c).
+	StageCompatibilityFolding Stage = 40
+	// StageCaseFolding normalizes the case of the text.
+	StageCaseFolding Stage = 50
+	// StageUnicodeFolding folds the text into a simpler Unicode form (ex: café = cafe).
+	StageUnicodeFolding Stage = 60
+	// StageCleanup removes or replaces unwanted characters.
+	StageCleanup Stage = 70
+	// StageTokenization splits the text into tokens.
+	StageTokenization Stage = 80
+)
+
+var stageNames = map[Stage]string{
+	StageDecode:               "decode",
+	StageMarkup:               "markup extraction",
+	StageInvisibleRemoval:     "invisible removal",
+	StageAnalysis:             "analysis",
+	StageCompatibilityFolding: "compatibility folding",
+	StageCaseFolding:          "case folding",
+	StageUnicodeFolding:       "unicode folding",
+	StageCleanup:              "cleanup",
+	StageTokenization:         "tokenization",
+}
+
+func (s Stage) String() string {
+	if name, ok := stageNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("stage(%d)", int(s))
+}
+
+// exclusiveStages are the stages where only the last option given takes effect.
+var exclusiveStages = []Stage{StageCaseFolding, StageTokenization}
+
+// Warning describes options that were accepted but probably do not do what the caller expects.
+type Warning struct {
+	Options []string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", strings.Join(w.Options, ", "), w.Message)
+}
+
+type step struct {
+	stage Stage
+	name  string
+	apply func(*Document)
+}
+
+// addStep registers a normalization step to be run at the given stage.
+func (d *Document) addStep(stage Stage, name string, apply func(*Document)) {
+	d.steps = append(d.steps, step{stage: stage, name: name, apply: apply})
+}
+
+// WithStage runs opt at the given stage. It can be used to place a custom Option in the
+// pipeline or to move a built-in option to another stage. opt is called once, on an empty
+// Document, and only the steps it registers are kept, with the name of the option that
+// registered them so the Warnings still tell it. A custom Option registering no steps
+// runs at the stage as a step itself.
+func WithStage(stage Stage, opt Option) Option {
+	return func(d *Document) {
+		var staged Document
+		opt(&staged)
+		if staged.optError != nil {
+			d.optError = staged.optError
+			return
+		}
+		if len(staged.steps) == 0 {
+			// a custom Option changing the Document
+			d.addStep(stage, optionName(opt), opt)
+			return
+		}
+		for _, s := range staged.steps {
+			s.stage = stage
+			d.steps = append(d.steps, s)
+		}
+	}
+}
+
+// optionName returns the name of the function of the Option, or "WithStage" if it has none.
+func optionName(opt Option) string {
+	f := runtime.FuncForPC(reflect.ValueOf(opt).Pointer())
+	if f == nil {
+		return "WithStage"
+	}
+	name := f.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// WithCustomOrder disables the canonical ordering of the stages. The normalization
+// options will run exactly in the order they were given.
+func WithCustomOrder() Option {
+	return func(d *Document) {
+		d.customOrder = true
+	}
+}
+
+// orderSteps returns the steps in the order they must run.
+func orderSteps(steps []step, customOrder bool) []step {
+	ordered := make([]step, len(steps))
+	copy(ordered, steps)
+	if !customOrder {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].stage < ordered[j].stage
+		})
+	}
+	return ordered
+}
+
+// runSteps applies the given steps. Steps registered while a step runs (ex: by a custom
+// Option) are applied right away.
+func (d *Document) runSteps(steps []step) {
+	for _, s := range steps {
+		s.apply(d)
+		d.trackOffsets()
+		if nested := d.steps; len(nested) > 0 {
+			d.steps = nil
+			d.runSteps(orderSteps(nested, d.customOrder))
+		}
+		if d.optError != nil {
+			return
+		}
+	}
+}
+
+// checkSteps looks for conflicting options and returns a Warning for each conflict found.
+func checkSteps(steps []step, customOrder bool) []Warning {
+	var warnings []Warning
+	for _, stage := range exclusiveStages {
+		var names []string
+		for _, s := range steps {
+			if s.stage == stage {
+				names = append(names, s.name)
+			}
+		}
+		if len(names) > 1 {
+			warnings = append(warnings, Warning{
+				Options: names,
+				Message: fmt.Sprintf("more than one %s option given, only %s takes effect",
+					stage, names[len(names)-1]),
+			})
+		}
+	}
+	if customOrder {
+		for i := 1; i < len(steps); i++ {
+			if steps[i].stage < steps[i-1].stage {
+				warnings = append(warnings, Warning{
+					Options: []string{steps[i-1].name, steps[i].name},
+					Message: fmt.Sprintf("%s runs after %s", steps[i].stage, steps[i-1].stage),
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// checkMatchScore returns a Warning if more than one of the options deciding whether the tokens
+// match were given.
+func checkMatchScore(names []string) []Warning {
+	if len(names) < 2 {
+		return nil
+	}
+	return []Warning{{
+		Options: names,
+		Message: fmt.Sprintf("more than one match score option given, only %s takes effect", names[len(names)-1]),
+	}}
+}
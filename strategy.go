@@ -0,0 +1,221 @@
+package gomtch
+
+import (
+	"fmt"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Technique is the way a token of the text was found to match the one of a Document.
+type Technique int
+
+const (
+	// TechniqueDirect compares the runes one by one, with the wildcards of the RunePolicy.
+	TechniqueDirect Technique = iota
+	// TechniqueRewrite compares the runes after the rewrite rules given WithRewriteRules.
+	TechniqueRewrite
+	// TechniqueVowelDropping finds the words written without some or all of their vowels (ex: "crpr" = corpora).
+	TechniqueVowelDropping
+	// TechniqueScrambledInterior finds the words with their interior letters scrambled (ex: "cproora" = corpora).
+	TechniqueScrambledInterior
+)
+
+var techniqueNames = map[Technique]string{
+	TechniqueDirect:            "direct",
+	TechniqueRewrite:           "rewrite rules",
+	TechniqueVowelDropping:     "vowel dropping",
+	TechniqueScrambledInterior: "scrambled interior",
+}
+
+func (t Technique) String() string {
+	if name, ok := techniqueNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("technique(%d)", int(t))
+}
+
+// Strategy configures a matching strategy.
+type Strategy struct {
+	// Penalty is subtracted from the score of each token found by the strategy, which must still reach
+	// the minimum given WithMinimumMatchScore or WithFuzziness (ex: none of them with FuzzinessExact),
+	// 100 without them. The minimum must be lowered for a strategy with a Penalty to find any token.
+	Penalty int
+	// MinLength is the length of the shortest token of the Document the strategy applies to.
+	// The short words have too many lookalikes (ex: "form" and "from").
+	MinLength int
+}
+
+var (
+	// DefaultVowelDropping is the Strategy used by WithVowelDropping when none is given.
+	DefaultVowelDropping = Strategy{Penalty: 20, MinLength: 5}
+	// DefaultScrambledInterior is the Strategy used by WithScrambledInterior when none is given.
+	DefaultScrambledInterior = Strategy{Penalty: 30, MinLength: 5}
+)
+
+const (
+	// minVowelDroppingKept is the number of runes a word written without vowels must keep.
+	minVowelDroppingKept = 3
+	// minScrambledLength is the length of the shortest word that can have its interior scrambled.
+	minScrambledLength = 4
+)
+
+// WithVowelDropping matches the tokens of the text that are the ones of the Document without some
+// or all of their vowels, keeping the other runes in order (ex: "crpr" or "corpr" = corpora).
+// The tokens found this way are reported by the Technique of the Match and lower its Score, which must
+// still reach the minimum match score (ex: WithMinimumMatchScore(80) for DefaultVowelDropping).
+func WithVowelDropping(strategy ...Strategy) Option {
+	return func(d *Document) {
+		s := DefaultVowelDropping
+		if len(strategy) != 0 {
+			s = strategy[0]
+		}
+		d.vowelDropping = &s
+	}
+}
+
+// WithScrambledInterior matches the tokens of the text that have the first and last letters of the ones
+// of the Document and the same interior letters in any order (ex: "cproora" = corpora).
+// The tokens found this way are reported by the Technique of the Match and lower its Score, which must
+// still reach the minimum match score (ex: WithMinimumMatchScore(70) for DefaultScrambledInterior).
+func WithScrambledInterior(strategy ...Strategy) Option {
+	return func(d *Document) {
+		s := DefaultScrambledInterior
+		if len(strategy) != 0 {
+			s = strategy[0]
+		}
+		d.scrambledInterior = &s
+	}
+}
+
+// matchStrategies compares the runes a of the text to the runes b of the pattern with the
+// strategies of the Document and returns the technique that found them.
+func (d Document) matchStrategies(a, b []rune) (Technique, bool) {
+	if s := d.vowelDropping; s != nil && len(b) >= s.MinLength && d.reachesMinimum(*s, len(b)) &&
+		d.isVowelDropped(a, b) {
+		return TechniqueVowelDropping, true
+	}
+	if s := d.scrambledInterior; s != nil && len(b) >= s.MinLength && d.reachesMinimum(*s, len(b)) &&
+		d.isScrambledInterior(a, b) {
+		return TechniqueScrambledInterior, true
+	}
+	return TechniqueDirect, false
+}
+
+// reachesMinimum reports whether the tokens of the given length found by the strategy reach
+// the minimum score of the Document, if it has one.
+func (d Document) reachesMinimum(s Strategy, length int) bool {
+	return d.minimumScore == nil || strategyScore(s.Penalty) >= d.minimumScore(length)
+}
+
+// strategyScore returns the score of a token found by a strategy with the penalty.
+func strategyScore(penalty int) int {
+	if penalty > 100 {
+		return 0
+	}
+	return 100 - penalty
+}
+
+// penalty returns the confidence lost by a token found with the technique.
+func (d Document) penalty(t Technique) int {
+	switch t {
+	case TechniqueVowelDropping:
+		return d.vowelDropping.Penalty
+	case TechniqueScrambledInterior:
+		return d.scrambledInterior.Penalty
+	}
+	return 0
+}
+
+// isVowelDropped reports whether a is b without some of its vowels.
+func (d Document) isVowelDropped(a, b []rune) bool {
+	if len(a) >= len(b) || len(a) < minVowelDroppingKept {
+		return false
+	}
+	i := 0
+	for _, r := range b {
+		if i < len(a) && d.equalRune(a[i], r) {
+			i++
+			continue
+		}
+		if !isVowel(r) {
+			return false
+		}
+	}
+	return i == len(a)
+}
+
+// isVowel reports whether the rune is a Latin vowel, in any case and with any accent.
+func isVowel(r rune) bool {
+	k := baseLetters(r)
+	return len(k) == 1 && strings.Contains("AEIOU", k)
+}
+
+// baseLetters returns the base letters of the compatibility decomposition of r, folded to ASCII when
+// possible, in the same case. White spaces and invisible characters have none.
+func baseLetters(r rune) string {
+	if unicode.IsSpace(r) || invisibleClassOf(r) != 0 {
+		return ""
+	}
+	if r < utf8.RuneSelf {
+		return string(foldedRune(r))
+	}
+	var b strings.Builder
+	for _, c := range norm.NFKD.String(string(r)) {
+		if unicode.In(c, unicode.Mn, unicode.Me) {
+			continue
+		}
+		folded, ok := compatibilityExtra(c)
+		if !ok {
+			folded, ok = latinFolding[c]
+		}
+		if !ok {
+			folded, ok = latinConfusables[c]
+		}
+		if !ok {
+			b.WriteRune(foldedRune(c))
+			continue
+		}
+		for _, f := range folded {
+			b.WriteRune(foldedRune(f))
+		}
+	}
+	return b.String()
+}
+
+// isScrambledInterior reports whether a has the first and last letters of b and its interior
+// letters in a different order.
+func (d Document) isScrambledInterior(a, b []rune) bool {
+	n := len(b)
+	if len(a) != n || n < minScrambledLength || !d.equalRune(a[0], b[0]) || !d.equalRune(a[n-1], b[n-1]) {
+		return false
+	}
+	counts := map[rune]int{}
+	same := true
+	for i := 1; i < n-1; i++ {
+		if !classOf(b[i]).isLetter() || !classOf(a[i]).isLetter() {
+			return false
+		}
+		counts[d.caseKey(b[i])]++
+		counts[d.caseKey(a[i])]--
+		same = same && d.equalRune(a[i], b[i])
+	}
+	if same {
+		return false
+	}
+	for _, c := range counts {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// caseKey returns the same rune for the runes equalRune reports as equal.
+func (d Document) caseKey(r rune) rune {
+	if d.caseInsensitive {
+		return foldedRune(r)
+	}
+	return r
+}
@@ -0,0 +1,88 @@
+package gomtch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchingStrategies(t *testing.T) {
+	// the penalties of the default strategies are at most 30
+	lowered := WithMinimumMatchScore(70)
+	tests := []struct {
+		name          string
+		opts          []Option
+		text          string
+		pattern       string
+		wantTechnique Technique
+		wantPenalty   int
+		want          bool
+	}{
+		{"disabled", nil, "crpr", "corpora", TechniqueDirect, 0, false},
+		{"noVowels", []Option{lowered, WithVowelDropping()}, "crpr", "corpora", TechniqueVowelDropping, 20, true},
+		{"someVowels", []Option{lowered, WithVowelDropping()}, "corpr", "corpora", TechniqueVowelDropping, 20, true},
+		{"accents", []Option{lowered, WithVowelDropping()}, "ccn", "cocaína", TechniqueVowelDropping, 20, true},
+		{"consonantDropped", []Option{lowered, WithVowelDropping()}, "cora", "corpora", TechniqueDirect, 0, false},
+		{"vowelsShortWord", []Option{lowered, WithVowelDropping()}, "ksk", "kiosk", TechniqueVowelDropping, 20, true},
+		{"vowelsTooShort", []Option{lowered, WithVowelDropping()}, "bk", "book", TechniqueDirect, 0, false},
+		{"vowelsMinLength", []Option{lowered, WithVowelDropping(Strategy{Penalty: 10, MinLength: 8})}, "crpr", "corpora",
+			TechniqueDirect, 0, false},
+		{"vowelsPhrase", []Option{lowered, WithVowelDropping(Strategy{Penalty: 10, MinLength: 5})}, "buy crpr now",
+			"buy corpora now", TechniqueVowelDropping, 10, true},
+		{"vowelsKeptTooFew", []Option{lowered, WithVowelDropping(Strategy{Penalty: 10, MinLength: 3})}, "buy crpr nw",
+			"buy corpora now", TechniqueDirect, 0, false},
+		{"scrambled", []Option{lowered, WithScrambledInterior()}, "cproora", "corpora", TechniqueScrambledInterior, 30, true},
+		{"scrambledLast", []Option{lowered, WithScrambledInterior()}, "cprooar", "corpora", TechniqueDirect, 0, false},
+		{"scrambledOtherLetters", []Option{lowered, WithScrambledInterior()}, "cprxora", "corpora", TechniqueDirect, 0,
+			false},
+		{"scrambledShortWord", []Option{lowered, WithScrambledInterior()}, "from", "form", TechniqueDirect, 0, false},
+		{"scrambledCase", []Option{lowered, WithScrambledInterior(), WithCaseInsensitiveMatching()}, "CPROORA", "corpora",
+			TechniqueScrambledInterior, 30, true},
+		{"both", []Option{lowered, WithScrambledInterior(), WithVowelDropping()}, "buy cproora crpr", "buy corpora corpora",
+			TechniqueScrambledInterior, 50, true},
+		{"direct", []Option{lowered, WithScrambledInterior(), WithVowelDropping()}, "buy corpora", "buy corpora",
+			TechniqueDirect, 0, true},
+		{"exact", []Option{WithFuzziness(FuzzinessExact), WithVowelDropping()}, "crpr", "corpora", TechniqueDirect, 0,
+			false},
+		{"fuzziness", []Option{WithFuzziness(FuzzinessAuto), WithVowelDropping()}, "crpr", "corpora",
+			TechniqueVowelDropping, 20, true},
+		{"minimum", []Option{WithMinimumMatchScore(90), WithScrambledInterior()}, "cproora", "corpora",
+			TechniqueDirect, 0, false},
+		{"minimumReached", []Option{WithMinimumMatchScore(70), WithScrambledInterior()}, "cproora", "corpora",
+			TechniqueScrambledInterior, 30, true},
+		{"defaultMinimum", []Option{WithVowelDropping()}, "crpr", "corpora", TechniqueDirect, 0, false},
+		{"explicitDefaultMinimum", []Option{WithMinimumMatchScore(100), WithVowelDropping()}, "crpr", "corpora",
+			TechniqueDirect, 0, false},
+		{"defaultMinimumNoPenalty", []Option{WithVowelDropping(Strategy{Penalty: 0, MinLength: 5})}, "crpr", "corpora",
+			TechniqueVowelDropping, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := locateIn(t, tt.text, tt.pattern, tt.opts...)
+			if ok != tt.want {
+				t.Fatalf("Locate() = %v, want %v", ok, tt.want)
+			}
+			if ok && (got.Technique != tt.wantTechnique || got.Penalty != tt.wantPenalty) {
+				t.Errorf("Locate() = %v %d, want %v %d", got.Technique, got.Penalty, tt.wantTechnique, tt.wantPenalty)
+			}
+		})
+	}
+}
+
+func TestMatchingStrategies_Find(t *testing.T) {
+	pattern, err := NewDocument("corpora", WithMinimumMatchScore(80), WithVowelDropping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDocument("compre crpr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Match{{Index: 0, Sequence: []rune("crpr"), Start: 1, End: 2, Score: 80, Technique: TechniqueVowelDropping,
+		Penalty: 20}}
+	if got := d.Find(pattern); !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+	if got := TechniqueScrambledInterior.String(); got != "scrambled interior" {
+		t.Errorf("String() = %v", got)
+	}
+}
@@ -0,0 +1,226 @@
+package gomtch
+
+import (
+	"github.com/jdkato/prose/tokenize"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Tokenizer splits a text into tokens. Any type with a Tokenize method can be used,
+// including the tokenizers of github.com/jdkato/prose/tokenize.
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// TokenizerFunc adapts an ordinary function to the Tokenizer interface.
+type TokenizerFunc func(text string) []string
+
+func (f TokenizerFunc) Tokenize(text string) []string {
+	return f(text)
+}
+
+// defaultTokenizer is used when no tokenization option is given.
+var defaultTokenizer = NewWhitespaceTokenizer()
+
+// NewWhitespaceTokenizer splits the text on any Unicode white space (spaces, tabs, new lines,
+// no-break spaces...). Sequential white spaces never produce empty tokens.
+func NewWhitespaceTokenizer() Tokenizer {
+	return TokenizerFunc(strings.Fields)
+}
+
+// NewJoinedTokenizer removes all the white spaces of the text and returns it as a single token.
+// It is useful for patterns expected to be written without spaces (ex: "cocaína branca" = cocainabranca).
+func NewJoinedTokenizer() Tokenizer {
+	return TokenizerFunc(func(text string) []string {
+		return []string{whiteSpaces.ReplaceAllString(text, "")}
+	})
+}
+
+// NewTreebankTokenizer uses the Penn Treebank word tokenizer of github.com/jdkato/prose.
+func NewTreebankTokenizer() Tokenizer {
+	return tokenize.NewTreebankWordTokenizer()
+}
+
+type regexpTokenizer struct {
+	pattern *regexp.Regexp
+	gaps    bool
+}
+
+// NewRegexpTokenizer splits the text using the pattern. If gaps is true the pattern matches the
+// separators between the tokens, otherwise it matches the tokens themselves. Empty tokens are discarded.
+func NewRegexpTokenizer(pattern *regexp.Regexp, gaps bool) Tokenizer {
+	return regexpTokenizer{pattern: pattern, gaps: gaps}
+}
+
+func (r regexpTokenizer) Tokenize(text string) []string {
+	var found []string
+	if r.gaps {
+		found = r.pattern.Split(text, -1)
+	} else {
+		found = r.pattern.FindAllString(text, -1)
+	}
+	tokens := found[:0]
+	for _, t := range found {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// NewWordTokenizer segments the text in words following the default word boundaries of
+// Unicode Standard Annex #29. Letters joined by apostrophes or periods (ex: d'água) and numbers
+// with separators (ex: 3,14) are kept as single tokens, punctuation marks become tokens of their own
+// and white spaces are discarded.
+func NewWordTokenizer() Tokenizer {
+	return TokenizerFunc(segmentWords)
+}
+
+// wordBreak is the Word_Break property of UAX #29 for the runes the segmentation cares about.
+type wordBreak uint8
+
+const (
+	wbOther wordBreak = iota
+	wbLetter
+	wbNumeric
+	wbMidLetter
+	wbMidNum
+	wbMidNumLet
+	wbKatakana
+	wbExtendNumLet
+	wbExtend
+	wbNewline
+	wbSpace
+)
+
+var (
+	wbMidLetterRunes = []rune{':', '\u00b7', '\u0387', '\u05f4', '\u2027', '\ufe13', '\ufe55', '\uff1a'}
+	wbMidNumRunes    = []rune{',', ';', '\u037e', '\u0589', '\u060c', '\u060d', '\u066c', '\u07f8', '\u2044',
+		'\ufe10', '\ufe14', '\ufe50', '\ufe54', '\uff0c', '\uff1b'}
+	wbMidNumLetRunes = []rune{'.', '\'', '\u2018', '\u2019', '\u2024', '\ufe52', '\uff07', '\uff0e'}
+)
+
+func wordBreakOf(r rune) wordBreak {
+	switch {
+	case r == '\n' || r == '\r' || r == '\v' || r == '\f' || r == 0x85 || r == 0x2028 || r == 0x2029:
+		return wbNewline
+	case unicode.IsSpace(r):
+		return wbSpace
+	case r == 0x200D || unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Cf):
+		return wbExtend
+	case unicode.In(r, unicode.Katakana):
+		return wbKatakana
+	case isNoSpaceScript(r):
+		// scripts written without spaces between words break around every character
+		return wbOther
+	case unicode.IsLetter(r):
+		return wbLetter
+	case unicode.Is(unicode.Nd, r):
+		return wbNumeric
+	case unicode.Is(unicode.Pc, r):
+		return wbExtendNumLet
+	case runeIn(r, wbMidNumLetRunes):
+		return wbMidNumLet
+	case runeIn(r, wbMidLetterRunes):
+		return wbMidLetter
+	case runeIn(r, wbMidNumRunes):
+		return wbMidNum
+	}
+	return wbOther
+}
+
+func runeIn(r rune, set []rune) bool {
+	for _, v := range set {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
+type wordUnit struct {
+	start int
+	wb    wordBreak
+}
+
+// segmentWords splits the text in the word boundaries of UAX #29 and discards the white spaces.
+func segmentWords(text string) []string {
+	var units []wordUnit
+	for i, r := range text {
+		wb := wordBreakOf(r)
+		if wb == wbExtend && len(units) > 0 {
+			// WB4: extending characters belong to the previous character
+			if prev := units[len(units)-1].wb; prev != wbNewline && prev != wbSpace {
+				continue
+			}
+			wb = wbOther
+		}
+		units = append(units, wordUnit{start: i, wb: wb})
+	}
+	var tokens []string
+	start := 0
+	for i := 1; i <= len(units); i++ {
+		if i < len(units) && !wordBreakBetween(units, i) {
+			continue
+		}
+		end := len(text)
+		if i < len(units) {
+			end = units[i].start
+		}
+		if wb := units[start].wb; wb != wbSpace && wb != wbNewline {
+			tokens = append(tokens, text[units[start].start:end])
+		}
+		start = i
+	}
+	return tokens
+}
+
+func isAHLetter(wb wordBreak) bool {
+	return wb == wbLetter
+}
+
+func isMidLetterQ(wb wordBreak) bool {
+	return wb == wbMidLetter || wb == wbMidNumLet
+}
+
+func isMidNumQ(wb wordBreak) bool {
+	return wb == wbMidNum || wb == wbMidNumLet
+}
+
+// wordBreakBetween reports whether there is a word boundary before units[i].
+func wordBreakBetween(units []wordUnit, i int) bool {
+	prev, cur := units[i-1].wb, units[i].wb
+	next, prev2 := wbOther, wbOther
+	if i+1 < len(units) {
+		next = units[i+1].wb
+	}
+	if i >= 2 {
+		prev2 = units[i-2].wb
+	}
+	switch {
+	case prev == wbNewline || cur == wbNewline:
+		return true
+	case prev == wbSpace && cur == wbSpace:
+		return false
+	case isAHLetter(prev) && isAHLetter(cur):
+		return false
+	case isAHLetter(prev) && isMidLetterQ(cur) && isAHLetter(next):
+		return false
+	case isAHLetter(prev2) && isMidLetterQ(prev) && isAHLetter(cur):
+		return false
+	case (prev == wbNumeric || isAHLetter(prev)) && (cur == wbNumeric || isAHLetter(cur)):
+		return false
+	case prev2 == wbNumeric && isMidNumQ(prev) && cur == wbNumeric:
+		return false
+	case prev == wbNumeric && isMidNumQ(cur) && next == wbNumeric:
+		return false
+	case prev == wbKatakana && cur == wbKatakana:
+		return false
+	case cur == wbExtendNumLet && (isAHLetter(prev) || prev == wbNumeric || prev == wbKatakana || prev == wbExtendNumLet):
+		return false
+	case prev == wbExtendNumLet && (isAHLetter(cur) || cur == wbNumeric || cur == wbKatakana):
+		return false
+	}
+	return true
+}
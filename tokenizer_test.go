@@ -0,0 +1,67 @@
+package gomtch
+
+import (
+	"github.com/jdkato/prose/tokenize"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTokenizers(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenizer Tokenizer
+		text      string
+		want      []string
+	}{
+		{"whitespace", NewWhitespaceTokenizer(), " coca\tcocaína\n\npara compra  venda ",
+			[]string{"coca", "cocaína", "para", "compra", "venda"}},
+		{"whitespaceEmpty", NewWhitespaceTokenizer(), " \n ", []string{}},
+		{"joined", NewJoinedTokenizer(), "cocaína \tbranca", []string{"cocaínabranca"}},
+		{"regexpGaps", NewRegexpTokenizer(regexp.MustCompile(`[\s,]+`), true), "coca, cocaína,,para",
+			[]string{"coca", "cocaína", "para"}},
+		{"regexpTokens", NewRegexpTokenizer(regexp.MustCompile(`\w+`), false), "coca, h4rd!",
+			[]string{"coca", "h4rd"}},
+		{"treebank", NewTreebankTokenizer(), "They'll buy cocaína.",
+			[]string{"They", "'ll", "buy", "cocaína", "."}},
+		{"prose", tokenize.NewRegexpTokenizer(`\s`, true, true), "coca cocaína", []string{"coca", "cocaína"}},
+		{"func", TokenizerFunc(func(text string) []string {
+			return strings.Split(text, "|")
+		}), "coca|cocaína", []string{"coca", "cocaína"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tokenizer.Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"words", "coca cocaína", []string{"coca", "cocaína"}},
+		{"punctuation", "un!lever, (amigo).", []string{"un", "!", "lever", ",", "(", "amigo", ")", "."}},
+		{"apostrophe", "d'água can't", []string{"d'água", "can't"}},
+		{"midLetterAtEnd", "amigo' boa", []string{"amigo", "'", "boa"}},
+		{"numbers", "3,14 1.000.000 h4rd", []string{"3,14", "1.000.000", "h4rd"}},
+		{"underscore", "snake_case", []string{"snake_case"}},
+		{"combiningMarks", "cocai\u0301na", []string{"cocai\u0301na"}},
+		{"newLines", "coca\r\ncocaína\n", []string{"coca", "cocaína"}},
+		{"ideographs", "可卡因 is", []string{"可", "卡", "因", "is"}},
+		{"katakana", "コカイン", []string{"コカイン"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewWordTokenizer().Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,39 @@ type Tokens struct {
 	Ids    []int
 }
 
+// NewTokens splits the special characters from the words of the tokens and assigns
+// an id to each of them in a single pass. It returns the same Tokens as
+// NewMappingFromTokens(tokens).Map().
+func NewTokens(tokens []string) Tokens {
+	t := Tokens{
+		Values: map[int][]rune{},
+	}
+	reference := map[string]int{}
+	for _, token := range tokens {
+		startSpecial, word, endSpecial := splitSpecials(token)
+		for _, s := range startSpecial {
+			t.add(string(s), len(t.Ids), reference)
+		}
+		t.add(word, len(t.Ids), reference)
+		for _, s := range endSpecial {
+			t.add(string(s), len(t.Ids), reference)
+		}
+	}
+	return t
+}
+
+// add appends the word found at the given position. reference holds the id of the words
+// already added.
+func (t *Tokens) add(word string, position int, reference map[string]int) {
+	if id, ok := reference[word]; ok {
+		t.Ids = append(t.Ids, id)
+		return
+	}
+	reference[word] = position
+	t.Values[position] = []rune(word)
+	t.Ids = append(t.Ids, position)
+}
+
 func (t Tokens) GetRunesByID(id int) []rune {
 	return t.Values[id]
 }
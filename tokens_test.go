@@ -1,7 +1,11 @@
 package gomtch
 
 import (
+	"fmt"
+	"io/ioutil"
+	"log"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -45,6 +49,28 @@ func TestMakeTokens(t *testing.T) {
 	}
 }
 
+func TestNewTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+	}{
+		{"default", []string{"something"}},
+		{"duplicatedWord", []string{"something", "else", "something"}},
+		{"specials", []string{":comida:", ".gostosa", "comida!?", "..."}},
+		{"multiByteSpecials", []string{"«comida»", "“boa”", "comida"}},
+		{"empty", []string{"coca", "", "cocaína", "", "para"}},
+		{"mapTokens", []string{"a", "b", "a", "c", "c", "a", "b", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := NewMappingFromTokens(tt.tokens).Map()
+			if got := NewTokens(tt.tokens); !reflect.DeepEqual(got, want) {
+				t.Errorf("NewTokens() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
 func TestTokens_mapTokens(t1 *testing.T) {
 	type args struct {
 		m Mapping
@@ -108,6 +134,12 @@ func Test_makeMapping(t *testing.T) {
 			".":       {3},
 			"gostosa": {4},
 		}},
+		{"endSpecialsBackwards", args{v: []string{"comida!?", "boa"}}, map[string][]int{
+			"comida": {0},
+			"?":      {1},
+			"!":      {2},
+			"boa":    {3},
+		}},
 		{"default5", args{v: []string{":comida", ".gostosa"}}, map[string][]int{
 			":":       {0},
 			"comida":  {1},
@@ -124,6 +156,29 @@ func Test_makeMapping(t *testing.T) {
 	}
 }
 
+func Test_splitSpecials(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		start []rune
+		word  string
+		end   []rune
+	}{
+		{"default", "amigo", nil, "amigo", nil},
+		{"onlySpecials", "...", nil, "...", nil},
+		{"both", ":amigo!?", []rune(":"), "amigo", []rune("?!")},
+		{"multiByte", "«amigo»", []rune("«"), "amigo", []rune("»")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, word, end := splitSpecials(tt.token)
+			if !reflect.DeepEqual(start, tt.start) || word != tt.word || !reflect.DeepEqual(end, tt.end) {
+				t.Errorf("splitSpecials() = %q %q %q, want %q %q %q", start, word, end, tt.start, tt.word, tt.end)
+			}
+		})
+	}
+}
+
 func Test_getEndSpecial(t *testing.T) {
 	type args struct {
 		token []rune
@@ -169,3 +224,80 @@ func Test_getStartSpecial(t *testing.T) {
 		})
 	}
 }
+
+// quadraticMap is the Mapping.Map implementation that looped over every word until
+// it found the next index. It is kept to compare against the linear implementation.
+func quadraticMap(m Mapping) Tokens {
+	tokens := Tokens{
+		Values: map[int][]rune{},
+	}
+	reference := map[string]int{}
+	var next int
+Outer:
+	for {
+		for word, indexes := range m.Items() {
+			for _, index := range indexes {
+				if index == next {
+					if id, ok := reference[word]; ok {
+						tokens.Ids = append(tokens.Ids, id)
+						next++
+						continue Outer
+					}
+					reference[word] = next
+					tokens.Values[next] = []rune(word)
+					tokens.Ids = append(tokens.Ids, next)
+					next++
+					continue Outer
+				}
+			}
+		}
+		break
+	}
+	return tokens
+}
+
+func sertoesTokens() []string {
+	b, err := ioutil.ReadFile("testdata/sertoes.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return strings.Fields(string(b))
+}
+
+func TestMapping_MapSertoes(t *testing.T) {
+	tokens := sertoesTokens()[:2000]
+	m := NewMappingFromTokens(tokens)
+	want := quadraticMap(m)
+	if got := m.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() differs from the quadratic implementation")
+	}
+	if got := NewTokens(tokens); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewTokens() differs from the quadratic implementation")
+	}
+}
+
+func BenchmarkMapping_Map(b *testing.B) {
+	tokens := sertoesTokens()
+	for _, size := range []int{1000, 5000, len(tokens)} {
+		m := NewMappingFromTokens(tokens[:size])
+		if size <= 5000 {
+			b.Run(fmt.Sprintf("quadratic%d", size), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					quadraticMap(m)
+				}
+			})
+		}
+		b.Run(fmt.Sprintf("Map%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.Map()
+			}
+		})
+		b.Run(fmt.Sprintf("NewTokens%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewTokens(tokens[:size])
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"golang.org/x/text/runes"
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
+	"sync"
 	"unicode"
 )
 
@@ -11,27 +12,35 @@ type Transformer interface {
 	Transform(s string) (string, error)
 }
 
+// ASCII removes the accents (combining marks) of the text (ex: canção = cancao).
+// The transformers created by NewASCII are pooled so an ASCII is safe for concurrent use.
 type ASCII struct {
-	t transform.Transformer
+	t    transform.Transformer
+	pool *sync.Pool
+}
+
+func newASCIITransformer() transform.Transformer {
+	return transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
 }
 
 func NewASCII() *ASCII {
-	return &ASCII{t: transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)}
+	return &ASCII{pool: &sync.Pool{
+		New: func() interface{} {
+			return newASCIITransformer()
+		},
+	}}
 }
 
 func (a ASCII) Transform(s string) (string, error) {
-	cntr := 1
-	dst := make([]byte, len(s)*cntr)
-	for {
-		nDst, _, err := a.t.Transform(dst, []byte(s), true)
-		if err != nil {
-			if err == transform.ErrShortDst {
-				cntr++
-				dst = make([]byte, len(s)*cntr)
-				continue
-			}
-			return "", err
-		}
-		return string(dst[:nDst]), nil
+	t := a.t
+	if a.pool != nil {
+		t = a.pool.Get().(transform.Transformer)
+		defer a.pool.Put(t)
+	}
+	// transform.String resets the transformer before using it
+	dst, _, err := transform.String(t, s)
+	if err != nil {
+		return "", err
 	}
+	return dst, nil
 }
@@ -45,3 +45,177 @@ func TestASCII_Transform(t *testing.T) {
 		})
 	}
 }
+
+func TestSkeleton_Transform(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"latin", "cocaína h4rd", "cocaína h4rd"},
+		{"cyrillic", "сосаіnа", "cocaina"},
+		{"cyrillicUpper", "СОСАІNА", "COCAINA"},
+		{"greek", "ϲοϲαινα", "cocaiva"},
+		{"notLatinPrototype", "кгт", "кгт"},
+		{"cyrillicShha", "Һһ", "Hh"},
+		{"greekAccent", "cοcά", "cocá"},
+		{"armenian", "cօcaіna", "cocaina"},
+		{"cherokee", "ᏟOᏟᎪINᎪ", "COCAINA"},
+		{"romanNumerals", "ⅽocaⅰna", "cocaina"},
+		{"cjk", "可卡因", "可卡因"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSkeleton().Transform(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Transform() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatinFold_Transform(t *testing.T) {
+	tests := []struct {
+		name           string
+		s              string
+		preserveLength bool
+		want           string
+	}{
+		{"empty", "", false, ""},
+		{"accents", "cocaína canção", false, "cocaina cancao"},
+		{"german", "Straße GROẞ", false, "Strasse GROSS"},
+		{"nordic", "Ærø Øresund þorp ðú", false, "AEro Oresund thorp du"},
+		{"polish", "Łódź źdźbło", false, "Lodz zdzblo"},
+		{"french", "Œuvre cœur", false, "OEuvre coeur"},
+		{"croatian", "Đakovo đak", false, "Dakovo dak"},
+		{"digraphs", "ǅabac Ǉubljana ĳs", false, "Dzabac LJubljana ijs"},
+		{"accentedNoDecomposition", "ǿ Ǣ ḏ", false, "o AE d"},
+		{"ipa", "ɓɗɠ", false, "bdg"},
+		{"decomposed", "cocaína", false, "cocaina"},
+		{"notLatin", "可卡因 кокаин", false, "可卡因 кокаин"},
+		{"preserveLength", "Straße œuvre ł", true, "Strase ouvre l"},
+		{"preserveLengthAccents", "cocaína", true, "cocaina"},
+		{"preserveLengthDecomposed", "cocaína", true, "cocaína"},
+		{"preserveLengthNotLatin", "한국 кокаин", true, "한국 кокаин"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLatinFold()
+			if tt.preserveLength {
+				l = NewLengthPreservingLatinFold()
+			}
+			got, err := l.Transform(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Transform() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// latinFoldExceptions are the letters of the Latin blocks that are not read as a basic Latin letter
+// or are turned, reversed or inverted, so they are not folded.
+var latinFoldExceptions = []rune("ƄƅƍƎƏƛƜƦƧƨƩƪƷƸƹƺƻƼƽƾƿǀǁǂǃǝǮǯǶǷȜȝɁɂɅ" +
+	"ⱯⱰⱵⱶⱷⱹⱻⱼ" +
+	"ꜢꜣꜤꜥꜦꜧꜪꜫꜬꜭꜮꜯꜾꜿꝔꝕꝜꝝꝢꝣꝨꝩꝪꝫꝬꝭꝮꝯꝰꝵꝶꝾꝿꞀꞁꞈꞋꞌꞍꞏꞫꞰꞱꞳꞴꞵꞶꞷꞺꞻꞼꞽꞾꞿ" +
+	"ꟓꟕꟵꟶꟷꟹꟺꟻꟼꟽꟾꟿ" +
+	"Ɤ꟎꟏꟒꟔ꟚꟛꟜ" +
+	"ꬰꬱꬳꬷꭀꭁꭂꭃꭄꭍꭏꭑꭓꭔꭕꭜꭝꭠꭡꭤꭥꭨꭩ")
+
+func TestLatinFold_Blocks(t *testing.T) {
+	blocks := []struct {
+		name     string
+		from, to rune
+	}{
+		{"Latin-1 Supplement", 0x00c0, 0x00ff},
+		{"Latin Extended-A", 0x0100, 0x017f},
+		{"Latin Extended-B", 0x0180, 0x024f},
+		{"Latin Extended Additional", 0x1e00, 0x1eff},
+		{"Latin Extended-C", 0x2c60, 0x2c7f},
+		{"Latin Extended-D", 0xa720, 0xa7ff},
+		{"Latin Extended-E", 0xab30, 0xab6f},
+	}
+	isASCIILetters := func(s string) bool {
+		for _, r := range s {
+			if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+				return false
+			}
+		}
+		return s != ""
+	}
+	folds, preserves := NewLatinFold(), NewLengthPreservingLatinFold()
+	for _, block := range blocks {
+		t.Run(block.name, func(t *testing.T) {
+			for r := block.from; r <= block.to; r++ {
+				if !unicode.IsLetter(r) || runeIn(r, latinFoldExceptions) {
+					continue
+				}
+				got, _ := folds.Transform(string(r))
+				if !isASCIILetters(got) {
+					t.Errorf("Transform(%q) got = %q, want ASCII letters", r, got)
+				}
+				got, _ = preserves.Transform(string(r))
+				if !isASCIILetters(got) || len(got) != 1 {
+					t.Errorf("length preserving Transform(%q) got = %q, want one ASCII letter", r, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCompatibility_Transform(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"plain", "cocaína 100%", "cocaína 100%"},
+		{"mathBold", "𝐜𝐨𝐫𝐩𝐨𝐫𝐚", "corpora"},
+		{"mathDoubleStruck", "𝕔𝕠𝕣𝕡𝕠𝕣𝕒", "corpora"},
+		{"fullwidth", "ｃｏｒｐｏｒａ １２", "corpora 12"},
+		{"circled", "ⓒⓞⓡⓟⓞⓡⓐ", "corpora"},
+		{"squared", "🄲🄾🅁🄿🄾🅁🄰", "CORPORA"},
+		{"negativeSquared", "🅲🅾🆁🅿🅾🆁🅰", "CORPORA"},
+		{"negativeCircled", "🅒🅞🅡🅟🅞🅡🅐", "CORPORA"},
+		{"regionalIndicators", "🇨🇴🇷🇵🇴🇷🇦", "CORPORA"},
+		{"superscripts", "ᶜᵒʳᵖᵒʳᵃ²", "corpora2"},
+		{"smallCapitals", "ᴄᴏʀᴘᴏʀᴀ", "corpora"},
+		{"ligatures", "ﬁne ﬂow", "fine flow"},
+		{"circledNumbers", "①❷➂➍⓫⓿", "1234110"},
+		{"accentedFullwidth", "ｃａｆé", "café"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCompatibility().Transform(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Transform() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCompatibilityFolding(t *testing.T) {
+	d, err := NewDocument("compre 🅲🅾🅲🅰í🅽🅰 e ｃｏｃａｉｎａ aqui",
+		WithCompatibilityFolding(), WithTransform(NewASCII()), WithSetLower())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "compre cocaina e cocaina aqui"; d.Text != want {
+		t.Errorf("Text = %q, want %q", d.Text, want)
+	}
+	pattern, err := NewDocument("cocaina")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Find(pattern); len(got) != 1 || got[0].Start != 1 {
+		t.Errorf("Find() = %v", got)
+	}
+}